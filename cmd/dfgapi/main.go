@@ -6,6 +6,7 @@ import (
 	"github.com/rwirdemann/datafrog/pkg/api"
 	"github.com/rwirdemann/datafrog/pkg/df"
 	"github.com/rwirdemann/datafrog/pkg/file"
+	_ "github.com/rwirdemann/datafrog/pkg/formats"
 	"log"
 	"net/http"
 )
@@ -16,6 +17,9 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := config.Serve(); err != nil {
+		log.Fatal(err)
+	}
 	testRepository := file.JSONTestRepository{}
 	api.RegisterHandler(config, router, testRepository)
 	err = router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
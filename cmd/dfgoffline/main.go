@@ -0,0 +1,108 @@
+// Command dfgoffline records or verifies a test against a finished log file,
+// e.g. a database log kept as CI artifact.
+//
+//	dfgoffline record -test create-job -log mysql.log.gz [-channel mysql] [-from 2024-04-08T12:50:00Z] [-to ...]
+//	dfgoffline verify -test create-job -log mysql.log.gz [-channel mysql] [-from ...] [-to ...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/file"
+	_ "github.com/rwirdemann/datafrog/pkg/formats"
+	"github.com/rwirdemann/datafrog/pkg/record"
+	"github.com/rwirdemann/datafrog/pkg/verify"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "record" && os.Args[1] != "verify") {
+		fmt.Fprintln(os.Stderr, "usage: dfgoffline record|verify -test name -log file [-channel name] [-from time] [-to time]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	testname := flags.String("test", "", "name of the test")
+	logfile := flags.String("log", "", "finished log file, may be gzip compressed")
+	channelName := flags.String("channel", "", "configured channel the log belongs to (default: the only configured channel)")
+	description := flags.String("description", "", "description of the recorded test")
+	fromFlag := flags.String("from", "", "start of the period in RFC3339 (default: open)")
+	toFlag := flags.String("to", "", "end of the period in RFC3339 (default: open)")
+	_ = flags.Parse(os.Args[2:])
+	if *testname == "" || *logfile == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := df.NewDefaultConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	channel, err := channel(config, *channelName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	from := parseTime(*fromFlag)
+	to := parseTime(*toFlag)
+
+	f, err := os.Open(*logfile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	repository := file.JSONTestRepository{}
+	switch os.Args[1] {
+	case "record":
+		if repository.Exists(*testname) {
+			log.Fatalf("test '%s' already exists", *testname)
+		}
+		tc := df.Testcase{Name: *testname, Description: *description, Channels: []df.ChannelSelection{{Name: channel.Name}}}
+		tc, err := record.RecordLog(tc, channel, f, from, to, repository)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Recorded %d expectation(s)\n", len(tc.Expectations))
+	case "verify":
+		report, err := verify.VerifyLog(*testname, channel, config, f, from, to, repository)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(report)
+		if report.Fulfilled < report.Expectations {
+			os.Exit(1)
+		}
+	}
+}
+
+// channel returns the configured channel name or the only configured channel if
+// name is empty.
+func channel(config df.Config, name string) (df.Channel, error) {
+	if name == "" {
+		if len(config.Channels) != 1 {
+			return df.Channel{}, fmt.Errorf("-channel is required")
+		}
+		return config.Channels[0], nil
+	}
+	if ch, ok := config.Channel(name); ok {
+		return ch, nil
+	}
+	return df.Channel{}, fmt.Errorf("channel '%s' does not exist", name)
+}
+
+func parseTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"embed"
 	"github.com/rwirdemann/datafrog/pkg/df"
+	_ "github.com/rwirdemann/datafrog/pkg/formats"
 	"github.com/rwirdemann/datafrog/pkg/web"
 	"github.com/rwirdemann/simpleweb/pkg/simpleweb"
 	"log"
@@ -3,6 +3,7 @@ module github.com/rwirdemann/datafrog
 go 1.21.6
 
 require (
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
 	github.com/rwirdemann/simpleweb v0.1.0
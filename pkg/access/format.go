@@ -0,0 +1,13 @@
+package access
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name:       "http-access",
+		LogFactory: func(channel df.Channel) df.LogFactory { return LogFactory{Follow: channel.Follow} },
+		Tokenizer:  Tokenizer{},
+	})
+}
@@ -0,0 +1,57 @@
+// Package access implements the http-access format, which reads the requests
+// of HTTP access logs written in the common or combined format of nginx and
+// Apache. It allows to record and verify the REST calls of a use case.
+package access
+
+import (
+	"io"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Log reads the requests of an access log. Each request is returned as single
+// line that contains the time in UTC, the remote address, the user, the size of
+// the response and the request's method, target and status:
+//
+//	2024-04-08T10:50:59Z	127.0.0.1 frank 2326	GET /jobs/5?tab=applications 200
+type Log struct {
+	df.LineReader
+}
+
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r)}
+}
+
+// NewAccessLog opens the log logfileName. The log is followed across rotation
+// and truncation according to the follow mode.
+func NewAccessLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader}, nil
+}
+
+// Timestamp returns the time of a request returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	r, err := ParseRequest(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.Time, nil
+}
+
+// Precision returns the resolution of the request times of access logs.
+func (m Log) Precision() time.Duration {
+	return time.Second
+}
+
+// NextLine returns the next request. Waits until a new request becomes
+// available or done is closed, static logs return io.EOF at their end instead.
+// Returns with an empty line and a nil error if the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, df.Parser(parseRequest))
+}
@@ -0,0 +1,67 @@
+package access
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextLine(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(`127.0.0.1 - frank [08/Apr/2024:12:50:59 +0200] "GET /jobs/5?tab=applications HTTP/1.1" 200 2326 "-" "curl/8.4.0"` + "\n" +
+		`10.0.0.7 - - [08/Apr/2024:12:51:00 +0200] "\x16\x03\x01" 400 157 "-" "-"` + "\n" +
+		`10.0.0.7 - - [08/Apr/2024:10:51:01 +0000] "POST /jobs HTTP/2.0" 201 -`))
+	assert.Equal(t, "2024-04-08T10:50:59Z\t127.0.0.1 frank 2326\tGET /jobs/5?tab=applications 200\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-08T10:51:01Z\t10.0.0.7 - -\tPOST /jobs 201\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTokenize(t *testing.T) {
+	s := "2024-04-08T10:50:59Z\t127.0.0.1 frank 2326\tGET /jobs/5/applications?status=open&page=2 200\n"
+	matches, pattern := df.MatchesPattern([]string{"GET /jobs"}, s)
+	assert.True(t, matches)
+	tokens := Tokenizer{}.Tokenize(s, []string{pattern})
+	assert.Equal(t, []string{"GET", "/jobs", "/5", "/applications", "?page=2", "?status=open", "200"}, tokens)
+	assert.Equal(t, []string{"GET", "/", "200"}, Tokenizer{}.Tokenize("2024-04-08T10:50:59Z\t127.0.0.1 - 12\tGET / 200", nil))
+	assert.Equal(t, map[string]string{"remote": "127.0.0.1", "user": "frank", "bytes": "2326"}, Tokenizer{}.Metadata(s))
+
+	// dynamic path segments become ignored diffs
+	diff, err := df.Expectation{Tokens: tokens}.Diff(Tokenizer{}.Tokenize(strings.Replace(s, "/5/", "/6/", 1), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, diff)
+}
+
+func TestTimestamp(t *testing.T) {
+	actual, err := Log{}.Timestamp("2024-04-08T10:50:59Z\t127.0.0.1 - 12\tGET / 200")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 8, 10, 50, 59, 0, time.UTC), actual)
+}
+
+func TestSkipsRequestsWithInvalidTime(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(`127.0.0.1 - - [31/Apr/2024:12:50:59 +0200] "GET / HTTP/1.1" 200 12` + "\n" +
+		`127.0.0.1 - - [08/Apr/2024:12:50:59 +0200] "DELETE /jobs/5 HTTP/1.1" 204 -` + "\r\n"))
+	assert.Equal(t, "2024-04-08T10:50:59Z\t127.0.0.1 - -\tDELETE /jobs/5 204\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMatchesRequestOfStartSecond(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(`127.0.0.1 - frank [08/Apr/2024:12:50:59 +0200] "GET /jobs/5 HTTP/1.1" 200 2326` + "\n"))
+	ts, err := l.Timestamp(nextLine(t, l))
+	assert.NoError(t, err)
+	start := time.Date(2024, 4, 8, 10, 50, 59, 750000000, time.UTC)
+	assert.True(t, df.InRecordingPeriod(df.FixedTimer{From: start}, l, ts))
+	assert.False(t, df.InRecordingPeriod(df.FixedTimer{From: start.Add(time.Second)}, l, ts))
+}
+
+func nextLine(t *testing.T, l Log) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
@@ -0,0 +1,86 @@
+package access
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// requestRegex matches the common and the combined log format of nginx and
+// Apache, the referer and user agent of the combined format are ignored:
+//
+//	127.0.0.1 - frank [08/Apr/2024:12:50:59 +0200] "GET /jobs/5?tab=applications HTTP/1.1" 200 2326 "-" "curl/8.4.0"
+//
+// Submatches are the remote address, the user, the time, the method, the
+// target and the status.
+var requestRegex = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "([A-Z]+) (\S+)(?: [^"]*)?" (\d{3}) (\d+|-)`)
+
+// timeLayout is the layout of the bracketed time of access logs.
+const timeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// parseRequest returns the request of line as single line, see Log. Returns
+// false if line is no valid access log entry.
+func parseRequest(line string) (string, bool) {
+	m := requestRegex.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return "", false
+	}
+	t, err := time.Parse(timeLayout, m[3])
+	if err != nil {
+		return "", false
+	}
+	return t.UTC().Format(time.RFC3339Nano) + "\t" + m[1] + " " + m[2] + " " + m[7] + "\t" + m[4] + " " + m[5] + " " + m[6] + "\n", true
+}
+
+// Request is a single request returned by Log.NextLine.
+type Request struct {
+	Time   time.Time
+	Remote string // address of the client
+	User   string // authenticated user or -
+	Bytes  string // size of the response body or -
+	Method string
+	Path   string
+	Query  string // query without leading ?
+	Status string
+}
+
+// ParseRequest parses a request returned by Log.NextLine.
+func ParseRequest(s string) (Request, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return Request{}, errors.New("string contains no request")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Request{}, errors.New("string contains no valid Timestamp")
+	}
+	client := strings.Fields(fields[1])
+	request := strings.Fields(fields[2])
+	if len(client) != 3 || len(request) != 3 {
+		return Request{}, errors.New("string contains no request")
+	}
+	path, query, _ := strings.Cut(request[1], "?")
+	return Request{Time: t, Remote: client[0], User: client[1], Bytes: client[2],
+		Method: request[0], Path: path, Query: query, Status: request[2]}, nil
+}
+
+// Tokens returns the method, the path segments, the query parameters sorted by
+// name and the status of r as tokens, e.g. ["GET", "/jobs", "/5", "?tab=open",
+// "200"].
+func (r Request) Tokens() []string {
+	tokens := []string{r.Method}
+	segments := strings.Split(strings.TrimPrefix(r.Path, "/"), "/")
+	for _, s := range segments {
+		tokens = append(tokens, "/"+s)
+	}
+	if r.Query != "" {
+		params := strings.Split(r.Query, "&")
+		sort.Strings(params)
+		for _, p := range params {
+			tokens = append(tokens, "?"+p)
+		}
+	}
+	return append(tokens, r.Status)
+}
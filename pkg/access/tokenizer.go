@@ -0,0 +1,29 @@
+package access
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Tokenizer tokenizes the requests returned by Log, see Request.Tokens. Each
+// path segment becomes a single token, thus dynamic segments like ids are
+// learned as ignored diffs. Patterns match the method and the target, e.g.
+// "GET /jobs", but requests aren't cut at the matching pattern.
+type Tokenizer struct {
+}
+
+func (t Tokenizer) Tokenize(s string, _ []string) []string {
+	r, err := ParseRequest(s)
+	if err != nil {
+		return df.Tokenize(s)
+	}
+	return r.Tokens()
+}
+
+// Metadata returns the remote address, the user and the response size of s.
+func (t Tokenizer) Metadata(s string) map[string]string {
+	r, err := ParseRequest(s)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{"remote": r.Remote, "user": r.User, "bytes": r.Bytes}
+}
@@ -2,16 +2,15 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rwirdemann/datafrog/pkg/df"
-	"github.com/rwirdemann/datafrog/pkg/mocks"
-	"github.com/rwirdemann/datafrog/pkg/mysql"
-	"github.com/rwirdemann/datafrog/pkg/postgres"
 	"github.com/rwirdemann/datafrog/pkg/record"
 	"github.com/rwirdemann/datafrog/pkg/verify"
 )
@@ -27,10 +26,57 @@ func (i invalidStateError) Error() string {
 	return "invalid recording state"
 }
 
+// runRequest is the optional json body of recording and verification requests.
+// Channels selects the configured channels to watch, Patterns overrides the
+// patterns of single channels by channel name. The description is only used
+// when a new test is recorded.
+type runRequest struct {
+	Channels    []string            `json:"channels"`
+	Patterns    map[string][]string `json:"patterns"`
+	Description string              `json:"description"`
+}
+
+// decodeRunRequest decodes the body of r. An empty body results in an empty
+// runRequest.
+func decodeRunRequest(r *http.Request) (runRequest, error) {
+	var req runRequest
+	if r.Body == nil {
+		return req, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return runRequest{}, err
+	}
+	return req, nil
+}
+
+// selection builds the channel selection of req. Without explicitly requested
+// channels the selection is based on defaults or on all configured channels if
+// no defaults are given.
+func (req runRequest) selection(defaults []df.ChannelSelection) []df.ChannelSelection {
+	var selection []df.ChannelSelection
+	switch {
+	case len(req.Channels) > 0:
+		for _, name := range req.Channels {
+			selection = append(selection, df.ChannelSelection{Name: name})
+		}
+	case len(defaults) > 0:
+		selection = append(selection, defaults...)
+	case len(req.Patterns) > 0:
+		for _, ch := range config.Channels {
+			selection = append(selection, df.ChannelSelection{Name: ch.Name})
+		}
+	}
+	for i, s := range selection {
+		if patterns, ok := req.Patterns[s.Name]; ok {
+			selection[i].Patterns = patterns
+		}
+	}
+	return selection
+}
+
 var config df.Config
 
-var runners = make(map[string]*record.Runner)
-var verifyRunners = make(map[string]*verify.Runner)
+var sessions = newSessionManager()
 
 // RegisterHandler registers http handler to record and verify testcases.
 func RegisterHandler(c df.Config, router *mux.Router, testRepository df.TestRepository) {
@@ -66,11 +112,38 @@ func RegisterHandler(c df.Config, router *mux.Router, testRepository df.TestRepo
 
 	// channel health
 	router.HandleFunc("/channels/{name}/health", ChannelHealth()).Methods("GET")
+
+	// running sessions
+	router.HandleFunc("/sessions", AllSessions()).Methods("GET")
+
+	// record test from uploaded log
+	router.HandleFunc("/tests/{name}/recordings/offline", RecordOffline(testRepository)).Methods("POST")
+
+	// verify test against uploaded log
+	router.HandleFunc("/tests/{name}/verifications/offline", VerifyOffline(testRepository)).Methods("PUT")
+}
+
+// AllSessions returns all running recording and verification sessions as
+// json-encoded HTTP response.
+func AllSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allSessions := struct {
+			Sessions []Session `json:"sessions"`
+		}{Sessions: sessions.all()}
+		b, err := json.Marshal(allSessions)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_, _ = w.Write(b)
+	}
 }
 
 func GetRecordingProgress() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		runner, ok := runners[mux.Vars(r)["name"]]
+		runner, ok := sessions.recording(mux.Vars(r)["name"])
 		if !ok {
 			http.Error(w, invalidStateError{}.Error(), http.StatusInternalServerError)
 			return
@@ -92,7 +165,7 @@ func GetRecordingProgress() http.HandlerFunc {
 
 func GetVerificationProgress() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		runner, ok := verifyRunners[mux.Vars(r)["name"]]
+		runner, ok := sessions.verification(mux.Vars(r)["name"])
 		if !ok {
 			http.Error(w, invalidStateError{}.Error(), http.StatusInternalServerError)
 			return
@@ -144,6 +217,10 @@ func DeleteTest(repository df.TestRepository) http.HandlerFunc {
 			http.Error(w, "name is required", http.StatusBadRequest)
 			return
 		}
+		if state := sessions.state(mux.Vars(r)["name"]); state != Idle {
+			http.Error(w, fmt.Sprintf("test is %s", state), http.StatusConflict)
+			return
+		}
 		if err := repository.Delete(mux.Vars(r)["name"]); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -170,26 +247,48 @@ func StartRecording(repository df.TestRepository) http.HandlerFunc {
 			return
 		}
 
-		channelName := config.Channels[0].Name // TODO: Get name from request
-		channel, ok := getChannel(channelName)
-		if !ok {
-			http.Error(w, fmt.Sprintf("channel '%s' does not exisit", channelName), http.StatusConflict)
+		req, err := decodeRunRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		channelLog, err := getLog(channel)
+		selection := req.selection(nil)
+		channels, err := config.SelectChannels(selection)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Logfile '%s' does not exist", channel.Log), http.StatusFailedDependency)
+			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
 
-		runners[testname] = record.NewRunner(testname, channel, repository, channelLog)
+		// keep the recorded channels, channels configured later aren't verified
+		if len(selection) == 0 {
+			for _, ch := range channels {
+				selection = append(selection, df.ChannelSelection{Name: ch.Name})
+			}
+		}
+
+		if err := sessions.begin(testname, Recording, channelNames(channels)); err != nil {
+			http.Error(w, err.Error(), transitionStatus(err))
+			return
+		}
 
-		// Start creates a new go routine
-		if err := runners[testname].Start(); err != nil {
+		channelLogs, err := getLogs(channels)
+		if err != nil {
+			sessions.end(testname)
+			http.Error(w, err.Error(), http.StatusFailedDependency)
+			return
+		}
+
+		tc := df.Testcase{Name: testname, Description: req.Description, Channels: selection}
+		group := record.NewGroup(tc, channels, channelLogs, repository)
+
+		// Start creates a new go routine for each channel
+		if err := group.Start(); err != nil {
+			sessions.end(testname)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		sessions.attachRecording(testname, group)
 
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.WriteHeader(http.StatusAccepted)
@@ -200,27 +299,19 @@ func StartRecording(repository df.TestRepository) http.HandlerFunc {
 // by the request param "name".
 func StopRecording() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Println("Recovered:", r)
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-		}()
-
 		if len(mux.Vars(r)["name"]) == 0 {
 			http.Error(w, "name is required", http.StatusBadRequest)
 			return
 		}
 
 		testname := mux.Vars(r)["name"]
-		runner, ok := runners[testname]
-		if !ok {
-			http.Error(w, "test is not being recorded", http.StatusNotFound)
+		session, err := sessions.stop(testname, Recording)
+		if err != nil {
+			http.Error(w, err.Error(), transitionStatus(err))
 			return
 		}
-		delete(runners, testname)
-		runner.Stop()
+		defer sessions.end(testname)
+		session.recording.Stop()
 	}
 }
 
@@ -260,27 +351,52 @@ func StartVerification(repository df.TestRepository) http.HandlerFunc {
 		}
 
 		testname := mux.Vars(request)["name"]
+		if state := sessions.state(testname); state != Idle {
+			err := invalidTransitionError{testname: testname, from: state, to: Verifying}
+			http.Error(writer, err.Error(), http.StatusConflict)
+			return
+		}
 
-		channelName := config.Channels[0].Name // TODO: Get name from request
-		channel, ok := getChannel(channelName)
-		if !ok {
-			http.Error(writer, fmt.Sprintf("channel '%s' does not exisit", channelName), http.StatusConflict)
+		tc, err := repository.Get(testname)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusNotFound)
 			return
 		}
 
-		channelLog, err := getLog(channel)
+		req, err := decodeRunRequest(request)
 		if err != nil {
-			http.Error(writer, fmt.Sprintf("Logfile '%s' does not exist", channel.Log), http.StatusFailedDependency)
+			http.Error(writer, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		verifyRunners[testname] = verify.NewRunner(testname, channel, config, channelLog, repository)
+		// reuse the channels the test was recorded with by default
+		channels, err := config.SelectChannels(req.selection(tc.Channels))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err := sessions.begin(testname, Verifying, channelNames(channels)); err != nil {
+			http.Error(writer, err.Error(), transitionStatus(err))
+			return
+		}
+
+		channelLogs, err := getLogs(channels)
+		if err != nil {
+			sessions.end(testname)
+			http.Error(writer, err.Error(), http.StatusFailedDependency)
+			return
+		}
+
+		group := verify.NewGroup(testname, channels, config, channelLogs, repository)
 
-		// Start creates a new go routine
-		if err := verifyRunners[testname].Start(); err != nil {
+		// Start creates a new go routine for each channel
+		if err := group.Start(); err != nil {
+			sessions.end(testname)
 			http.Error(writer, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		sessions.attachVerification(testname, group)
 
 		writer.Header().Set("Access-Control-Allow-Origin", "*")
 		writer.WriteHeader(http.StatusAccepted)
@@ -292,13 +408,13 @@ func StartVerification(repository df.TestRepository) http.HandlerFunc {
 func StopVerify() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		testname := mux.Vars(request)["name"]
-		runner, ok := verifyRunners[testname]
-		if !ok {
-			http.Error(writer, "test is not being verified", http.StatusNotFound)
+		session, err := sessions.stop(testname, Verifying)
+		if err != nil {
+			http.Error(writer, err.Error(), transitionStatus(err))
 			return
 		}
-		delete(verifyRunners, testname)
-		if err := runner.Stop(); err != nil {
+		defer sessions.end(testname)
+		if err := session.verification.Stop(); err != nil {
 			http.Error(writer, err.Error(), http.StatusNotFound)
 			return
 		}
@@ -307,9 +423,127 @@ func StopVerify() http.HandlerFunc {
 	}
 }
 
+// RecordOffline returns a http handler that records the test given in the
+// request param "name" from the uploaded log in the request body. The body may
+// be gzip compressed. Query params: channel (required if more than one channel
+// is configured), from and to (RFC3339, optional) and description.
+func RecordOffline(repository df.TestRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		testname := mux.Vars(r)["name"]
+		if repository.Exists(testname) {
+			http.Error(w, fmt.Sprintf("test '%s' already exists", testname), http.StatusConflict)
+			return
+		}
+
+		channel, from, to, err := offlineParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := sessions.begin(testname, Recording, []string{channel.Name}); err != nil {
+			http.Error(w, err.Error(), transitionStatus(err))
+			return
+		}
+		defer sessions.end(testname)
+
+		tc := df.Testcase{
+			Name:        testname,
+			Description: r.URL.Query().Get("description"),
+			Channels:    []df.ChannelSelection{{Name: channel.Name}},
+		}
+		tc, err = record.RecordLog(tc, channel, r.Body, from, to, repository)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		b, err := json.Marshal(tc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(b)
+	}
+}
+
+// VerifyOffline returns a http handler that verifies the test given in the
+// request param "name" against the uploaded log in the request body and
+// responds with the verification report. Accepts the same body and query
+// params as RecordOffline.
+func VerifyOffline(repository df.TestRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		testname := mux.Vars(r)["name"]
+		if !repository.Exists(testname) {
+			http.Error(w, fmt.Sprintf("test '%s' does not exist", testname), http.StatusNotFound)
+			return
+		}
+
+		channel, from, to, err := offlineParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := sessions.begin(testname, Verifying, []string{channel.Name}); err != nil {
+			http.Error(w, err.Error(), transitionStatus(err))
+			return
+		}
+		defer sessions.end(testname)
+
+		report, err := verify.VerifyLog(testname, channel, config, r.Body, from, to, repository)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		b, err := json.Marshal(report)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_, _ = w.Write(b)
+	}
+}
+
+// offlineParams reads channel and time window of an offline run from the query
+// params of r.
+func offlineParams(r *http.Request) (df.Channel, time.Time, time.Time, error) {
+	var from, to time.Time
+	name := r.URL.Query().Get("channel")
+	if name == "" {
+		if len(config.Channels) != 1 {
+			return df.Channel{}, from, to, errors.New("channel is required")
+		}
+		name = config.Channels[0].Name
+	}
+	channel, ok := config.Channel(name)
+	if !ok {
+		return df.Channel{}, from, to, fmt.Errorf("channel '%s' does not exist", name)
+	}
+
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return df.Channel{}, from, to, err
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return df.Channel{}, from, to, err
+		}
+	}
+	return channel, from, to, nil
+}
+
 // ChannelHealth checks the health of the channel "name" by tailing the
 // associated log file, triggering the SUT to force a log update and ensures that
-// the log file was updated.
+// the log file was updated within the channel's grace timeout.
 func ChannelHealth() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		if len(mux.Vars(request)["name"]) == 0 {
@@ -323,7 +557,7 @@ func ChannelHealth() http.HandlerFunc {
 		}
 
 		channelName := mux.Vars(request)["name"]
-		channel, ok := getChannel(channelName)
+		channel, ok := config.Channel(channelName)
 		if !ok {
 			http.Error(writer, fmt.Sprintf("channel '%s' does not exisit", channelName), http.StatusConflict)
 			return
@@ -334,6 +568,7 @@ func ChannelHealth() http.HandlerFunc {
 			http.Error(writer, fmt.Sprintf("Logfile '%s' does not exist", channel.Log), http.StatusConflict)
 			return
 		}
+		defer channelLog.Close()
 
 		// jump to logfile end
 		err := channelLog.Tail()
@@ -342,23 +577,25 @@ func ChannelHealth() http.HandlerFunc {
 			return
 		}
 
-		// trigger SUT and give it some time to update the channel log
+		// trigger SUT to update the channel log
 		_, err = http.Get(config.SUT.BaseURL)
 		if err != nil {
 			writer.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		time.Sleep(250 * time.Millisecond)
 
-		// create go routine to interrupt blocking the NextLine call after 200ms
-		c := make(chan struct{})
-		go func() {
-			time.Sleep(200 * time.Millisecond)
-			close(c)
-		}()
+		// NextLine returns as soon as the SUT updated the channel log, the
+		// update is awaited for the grace timeout of the channel
+		wait := channel.Grace()
+		if wait <= 0 {
+			wait = df.DefaultGraceTimeout
+		}
+		done := make(chan struct{})
+		deadline := time.AfterFunc(wait, func() { close(done) })
+		defer deadline.Stop()
 
 		// read next line from updated log file
-		line, err := channelLog.NextLine(c)
+		line, err := channelLog.NextLine(done)
 		if err != nil || line == "" {
 			writer.WriteHeader(http.StatusFailedDependency)
 			return
@@ -368,27 +605,35 @@ func ChannelHealth() http.HandlerFunc {
 	}
 }
 
-func getChannel(name string) (df.Channel, bool) {
-	for _, ch := range config.Channels {
-		if ch.Name == name {
-			return ch, true
+func channelNames(channels []df.Channel) []string {
+	var names []string
+	for _, ch := range channels {
+		names = append(names, ch.Name)
+	}
+	return names
+}
+
+// getLogs opens the logs of all given channels. Already opened logs are closed
+// if one of the logs can't be opened.
+func getLogs(channels []df.Channel) ([]df.Log, error) {
+	var logs []df.Log
+	for _, channel := range channels {
+		channelLog, err := getLog(channel)
+		if err != nil {
+			for _, l := range logs {
+				l.Close()
+			}
+			return nil, fmt.Errorf("channel '%s': %w", channel.Name, err)
 		}
+		logs = append(logs, channelLog)
 	}
-	return df.Channel{}, false
+	return logs, nil
 }
 
 func getLog(channel df.Channel) (df.Log, error) {
-	var logFactory df.LogFactory
-	if channel.Format == "mysql" {
-		logFactory = mysql.LogFactory{}
-	}
-	if channel.Format == "postgres" {
-		logFactory = postgres.LogFactory{}
+	format, err := df.LookupFormat(channel.Format)
+	if err != nil {
+		return nil, err
 	}
-	// TODO: This is fix for test_handler. In real useage, we must not use it and should return an error here!
-	if logFactory == nil {
-		logFactory = mocks.LogFactory{}
-	}
-
-	return logFactory.Create(channel.Log)
+	return format.LogFactory(channel).Create(channel.Log)
 }
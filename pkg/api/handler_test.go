@@ -2,12 +2,18 @@ package api
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rwirdemann/datafrog/pkg/df"
+	_ "github.com/rwirdemann/datafrog/pkg/formats"
 	"github.com/rwirdemann/datafrog/pkg/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -16,22 +22,24 @@ var testname string
 
 func init() {
 	testname = "create-job"
+	df.RegisterFormat(mocks.Format)
 }
 
 func TestStartRecordingNoChannels(t *testing.T) {
 	repository := &mocks.TestRepository{}
-	rr := startRecording(t, repository)
+	rr := startRecording(t, repository, nil)
 	assert.Equal(t, http.StatusFailedDependency, rr.Code)
 }
 
 func TestRecording(t *testing.T) {
-	config.Channels = append(config.Channels, df.Channel{})
+	config.Channels = append(config.Channels, df.Channel{Format: "mock"})
 	repository := &mocks.TestRepository{}
-	rr := startRecording(t, repository)
+	rr := startRecording(t, repository, nil)
 	assert.Equal(t, http.StatusAccepted, rr.Code)
-	runner, ok := runners[testname]
+	_, ok := sessions.recording(testname)
 	assert.True(t, ok)
-	runner.Stop()
+	rr = stopRecording(t)
+	assert.Equal(t, http.StatusOK, rr.Code)
 	tc, err := repository.Get(testname)
 	if err != nil {
 		t.Fatal(err)
@@ -40,18 +48,136 @@ func TestRecording(t *testing.T) {
 }
 
 func TestVerification(t *testing.T) {
-	config.Channels = append(config.Channels, df.Channel{})
+	config.Channels = append(config.Channels, df.Channel{Format: "mock"})
 	repository := &mocks.TestRepository{Testcases: []df.Testcase{{Name: testname}}}
-	rr := startVerification(t, repository)
+	rr := startVerification(t, repository, nil)
 	assert.Equal(t, http.StatusAccepted, rr.Code)
-	runner, ok := verifyRunners[testname]
+	_, ok := sessions.verification(testname)
 	assert.True(t, ok)
-	err := runner.Stop()
-	assert.NoError(t, err)
+	rr = stopVerification(t)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
 }
 
-func startRecording(t *testing.T, repository df.TestRepository) *httptest.ResponseRecorder {
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/tests/%s/recordings", testname), nil)
+func TestRecordingWithChannelSelection(t *testing.T) {
+	defer func(channels []df.Channel) { config.Channels = channels }(config.Channels)
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mock"}, {Name: "postgres", Format: "mock", Patterns: []string{"update"}}}
+	repository := &mocks.TestRepository{}
+	body := `{"channels": ["postgres"], "patterns": {"postgres": ["insert"]}, "description": "creates a job"}`
+	rr := startRecording(t, repository, strings.NewReader(body))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	stopRecording(t)
+	tc, err := repository.Get(testname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "creates a job", tc.Description)
+	assert.Equal(t, []df.ChannelSelection{{Name: "postgres", Patterns: []string{"insert"}}}, tc.Channels)
+}
+
+func TestRecordingKeepsRecordedChannels(t *testing.T) {
+	defer func(channels []df.Channel) { config.Channels = channels }(config.Channels)
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mock"}, {Name: "postgres", Format: "mock"}}
+	repository := &mocks.TestRepository{}
+	rr := startRecording(t, repository, nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	stopRecording(t)
+	tc, err := repository.Get(testname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []df.ChannelSelection{{Name: "mysql"}, {Name: "postgres"}}, tc.Channels)
+
+	// channels configured after the recording aren't verified
+	config.Channels = append(config.Channels, df.Channel{Name: "redis", Format: "mock"})
+	rr = startVerification(t, repository, nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	all := sessions.all()
+	stopVerification(t)
+	assert.Len(t, all, 1)
+	assert.Equal(t, []string{"mysql", "postgres"}, all[0].Channels)
+}
+
+func TestRecordingUnknownChannel(t *testing.T) {
+	defer func(channels []df.Channel) { config.Channels = channels }(config.Channels)
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mock"}}
+	rr := startRecording(t, &mocks.TestRepository{}, strings.NewReader(`{"channels": ["oracle"]}`))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestVerificationReusesRecordedChannels(t *testing.T) {
+	defer func(channels []df.Channel) { config.Channels = channels }(config.Channels)
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mock"}, {Name: "postgres", Format: "mock"}}
+	tc := df.Testcase{Name: testname, Channels: []df.ChannelSelection{{Name: "postgres"}}}
+	rr := startVerification(t, &mocks.TestRepository{Testcases: []df.Testcase{tc}}, nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	group, _ := sessions.verification(testname)
+	stopVerification(t)
+	assert.Equal(t, []df.ChannelSelection{{Name: "postgres"}}, group.Testcase().Channels)
+}
+
+func TestRecordingAlreadyRunning(t *testing.T) {
+	defer func(channels []df.Channel) { config.Channels = channels }(config.Channels)
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mock"}}
+	repository := &mocks.TestRepository{}
+	rr := startRecording(t, repository, nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	defer stopRecording(t)
+
+	rr = startRecording(t, repository, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = startVerification(t, repository, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = stopVerification(t)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	all := sessions.all()
+	assert.Len(t, all, 1)
+	assert.Equal(t, Recording, all[0].State)
+	assert.Equal(t, []string{"mysql"}, all[0].Channels)
+}
+
+func TestStopIdleTest(t *testing.T) {
+	rr := stopRecording(t)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = stopVerification(t)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAllSessions(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/sessions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	AllSessions()(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"sessions": []}`, rr.Body.String())
+}
+
+func stopRecording(t *testing.T) *httptest.ResponseRecorder {
+	return serve(t, http.MethodDelete, fmt.Sprintf("/tests/%s/recordings", testname), "/tests/{name}/recordings", StopRecording())
+}
+
+func stopVerification(t *testing.T) *httptest.ResponseRecorder {
+	return serve(t, http.MethodDelete, fmt.Sprintf("/tests/%s/verifications", testname), "/tests/{name}/verifications", StopVerify())
+}
+
+func serve(t *testing.T, method, url, route string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(method)
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func startRecording(t *testing.T, repository df.TestRepository, body io.Reader) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/tests/%s/recordings", testname), body)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -62,8 +188,8 @@ func startRecording(t *testing.T, repository df.TestRepository) *httptest.Respon
 	return rr
 }
 
-func startVerification(t *testing.T, repository df.TestRepository) *httptest.ResponseRecorder {
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/tests/%s/verifications", testname), nil)
+func startVerification(t *testing.T, repository df.TestRepository, body io.Reader) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/tests/%s/verifications", testname), body)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -73,3 +199,65 @@ func startVerification(t *testing.T, repository df.TestRepository) *httptest.Res
 	r.ServeHTTP(rr, req)
 	return rr
 }
+
+func TestOfflineRecordingAndVerification(t *testing.T) {
+	defer func(channels []df.Channel) { config.Channels = channels }(config.Channels)
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mysql", Patterns: []string{"insert"}}}
+	repository := &mocks.TestRepository{}
+
+	logs := "2024-04-08T12:50:59.605638Z	 2609 Query	insert into job (description, id) values ('World', 3)\n"
+	url := fmt.Sprintf("/tests/%s/recordings/offline?from=2024-04-08T12:50:00Z", testname)
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/tests/{name}/recordings/offline", RecordOffline(repository)).Methods("POST")
+	r.HandleFunc("/tests/{name}/verifications/offline", VerifyOffline(repository)).Methods("PUT")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, Idle, sessions.state(testname))
+
+	logs = "2024-04-09T12:50:59.605638Z	 2609 Query	insert into job (description, id) values ('World', 4)\n"
+	url = fmt.Sprintf("/tests/%s/verifications/offline?channel=mysql", testname)
+	req, err = http.NewRequest(http.MethodPut, url, strings.NewReader(logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"fulfilled":1`)
+}
+
+func TestChannelHealth(t *testing.T) {
+	defer func(c df.Config) { config = c }(config)
+	name := filepath.Join(t.TempDir(), "general.log")
+	if err := os.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	config.Channels = []df.Channel{{Name: "mysql", Format: "mysql", Log: name, Follow: df.FollowPoll, GraceTimeout: 200}}
+
+	// the SUT writes a statement to the channel log
+	sut := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+		assert.Nil(t, err)
+		_, err = f.WriteString("2024-04-08T09:39:15.070009Z\t 2549 Query\tselect 1\n")
+		assert.Nil(t, err)
+		assert.Nil(t, f.Close())
+	}))
+	defer sut.Close()
+	config.SUT.BaseURL = sut.URL
+	rr := serve(t, http.MethodGet, "/channels/mysql/health", "/channels/{name}/health", ChannelHealth())
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// the log isn't updated within the grace timeout
+	idle := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer idle.Close()
+	config.SUT.BaseURL = idle.URL
+	start := time.Now()
+	rr = serve(t, http.MethodGet, "/channels/mysql/health", "/channels/{name}/health", ChannelHealth())
+	assert.Equal(t, http.StatusFailedDependency, rr.Code)
+	assert.Less(t, time.Since(start), time.Second)
+}
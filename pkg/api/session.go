@@ -0,0 +1,213 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/record"
+	"github.com/rwirdemann/datafrog/pkg/verify"
+)
+
+// State represents the state of a test within the API. Tests without a
+// session are idle. A session fails if one of its runners was aborted by an
+// error. Failed sessions need to be stopped like running ones. Legal
+// transitions are:
+//
+//	idle -> recording [-> failed] -> stopping -> idle
+//	idle -> verifying [-> failed] -> stopping -> idle
+type State string
+
+const (
+	Idle      State = "idle"
+	Recording State = "recording"
+	Verifying State = "verifying"
+	Stopping  State = "stopping"
+	Failed    State = "failed"
+)
+
+// Session describes a running recording or verification session.
+type Session struct {
+	Testname string    `json:"testname"`
+	State    State     `json:"state"`
+	Started  time.Time `json:"started"`
+	Channels []string  `json:"channels"`
+	Error    string    `json:"error,omitempty"` // error that failed the session
+
+	// Switches lists the rotations and truncations of the channels' log files
+	// that were followed during the session.
+	Switches []df.LogSwitch `json:"switches,omitempty"`
+}
+
+// invalidTransitionError informs clients that a test can't change from its
+// current state to the requested state, e.g. a test that is being recorded
+// can't be verified at the same time.
+type invalidTransitionError struct {
+	testname string
+	from     State
+	to       State
+}
+
+func (e invalidTransitionError) Error() string {
+	return fmt.Sprintf("test '%s' is %s and can't change to %s", e.testname, e.from, e.to)
+}
+
+// session is the registry entry of a running test.
+type session struct {
+	Session
+	recording    *record.Group
+	verification *verify.Group
+}
+
+// refresh updates the log file switches of the session and moves it into the
+// failed state if one of its runners was aborted by an error.
+func (s *session) refresh() {
+	if s.recording != nil {
+		s.Switches = s.recording.Switches()
+	}
+	if s.verification != nil {
+		s.Switches = s.verification.Switches()
+	}
+	if s.State != Recording && s.State != Verifying {
+		return
+	}
+	var err error
+	if s.recording != nil {
+		err = s.recording.Err()
+	}
+	if s.verification != nil {
+		err = s.verification.Err()
+	}
+	if err != nil {
+		s.State = Failed
+		s.Error = err.Error()
+	}
+}
+
+// sessionManager keeps track of all running sessions. It is safe for
+// concurrent use by multiple http handlers.
+type sessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func newSessionManager() *sessionManager {
+	return &sessionManager{sessions: make(map[string]*session)}
+}
+
+// state returns the current state of test testname.
+func (m *sessionManager) state(testname string) State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[testname]; ok {
+		s.refresh()
+		return s.State
+	}
+	return Idle
+}
+
+// begin creates a new session in state for the idle test testname. The
+// session's runners are attached via attachRecording or attachVerification
+// once they were started successfully.
+func (m *sessionManager) begin(testname string, state State, channels []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[testname]; ok {
+		return invalidTransitionError{testname: testname, from: s.State, to: state}
+	}
+	m.sessions[testname] = &session{Session: Session{Testname: testname, State: state, Started: time.Now(), Channels: channels}}
+	return nil
+}
+
+func (m *sessionManager) attachRecording(testname string, g *record.Group) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[testname]; ok {
+		s.recording = g
+	}
+}
+
+func (m *sessionManager) attachVerification(testname string, g *verify.Group) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[testname]; ok {
+		s.verification = g
+	}
+}
+
+// stop moves the session of testname from state or from failed to stopping and
+// returns it. The caller must call end after the session's runners have been
+// stopped.
+func (m *sessionManager) stop(testname string, state State) (*session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[testname]
+	if !ok {
+		return nil, invalidTransitionError{testname: testname, from: Idle, to: Stopping}
+	}
+	s.refresh()
+	failed := s.State == Failed && ((state == Recording && s.recording != nil) || (state == Verifying && s.verification != nil))
+
+	// runners that aren't attached yet are still starting and can't be stopped
+	if (s.State != state && !failed) || (s.recording == nil && s.verification == nil) {
+		return nil, invalidTransitionError{testname: testname, from: s.State, to: Stopping}
+	}
+	s.State = Stopping
+	return s, nil
+}
+
+// end removes the session of testname, thus the test becomes idle again.
+func (m *sessionManager) end(testname string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, testname)
+}
+
+// recording returns the recording group of testname if the test is being
+// recorded.
+func (m *sessionManager) recording(testname string) (*record.Group, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[testname]
+	if !ok || s.recording == nil {
+		return nil, false
+	}
+	return s.recording, true
+}
+
+// verification returns the verification group of testname if the test is being
+// verified.
+func (m *sessionManager) verification(testname string) (*verify.Group, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[testname]
+	if !ok || s.verification == nil {
+		return nil, false
+	}
+	return s.verification, true
+}
+
+// all returns all running sessions ordered by testname.
+func (m *sessionManager) all() []Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := []Session{}
+	for _, s := range m.sessions {
+		s.refresh()
+		all = append(all, s.Session)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Testname < all[j].Testname })
+	return all
+}
+
+// transitionStatus maps err to its http status code. Stopping an idle test
+// results in 404, all other invalid transitions in 409.
+func transitionStatus(err error) int {
+	if e, ok := err.(invalidTransitionError); ok && e.from == Idle {
+		return http.StatusNotFound
+	}
+	return http.StatusConflict
+}
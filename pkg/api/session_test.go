@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/mocks"
+	"github.com/rwirdemann/datafrog/pkg/record"
+	"github.com/rwirdemann/datafrog/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTransitions(t *testing.T) {
+	type step struct {
+		action string // begin, attach, stop or end
+		state  State  // state passed to begin, attach and stop
+		status int    // http status of the rejected transition, 0 if legal
+		want   State  // state of the test after the step
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{name: "recording", steps: []step{
+			{action: "stop", state: Recording, status: http.StatusNotFound, want: Idle},
+			{action: "begin", state: Recording, want: Recording},
+			{action: "stop", state: Recording, status: http.StatusConflict, want: Recording}, // runners are starting
+			{action: "attach", state: Recording, want: Recording},
+			{action: "begin", state: Recording, status: http.StatusConflict, want: Recording},
+			{action: "begin", state: Verifying, status: http.StatusConflict, want: Recording},
+			{action: "stop", state: Verifying, status: http.StatusConflict, want: Recording},
+			{action: "stop", state: Recording, want: Stopping},
+			{action: "stop", state: Recording, status: http.StatusConflict, want: Stopping},
+			{action: "begin", state: Verifying, status: http.StatusConflict, want: Stopping},
+			{action: "end", want: Idle},
+			{action: "begin", state: Verifying, want: Verifying},
+		}},
+		{name: "verifying", steps: []step{
+			{action: "stop", state: Verifying, status: http.StatusNotFound, want: Idle},
+			{action: "begin", state: Verifying, want: Verifying},
+			{action: "stop", state: Verifying, status: http.StatusConflict, want: Verifying}, // runners are starting
+			{action: "attach", state: Verifying, want: Verifying},
+			{action: "begin", state: Verifying, status: http.StatusConflict, want: Verifying},
+			{action: "begin", state: Recording, status: http.StatusConflict, want: Verifying},
+			{action: "stop", state: Recording, status: http.StatusConflict, want: Verifying},
+			{action: "stop", state: Verifying, want: Stopping},
+			{action: "stop", state: Verifying, status: http.StatusConflict, want: Stopping},
+			{action: "begin", state: Recording, status: http.StatusConflict, want: Stopping},
+			{action: "end", want: Idle},
+			{action: "begin", state: Recording, want: Recording},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newSessionManager()
+			for i, s := range tt.steps {
+				var err error
+				switch s.action {
+				case "begin":
+					err = m.begin("test", s.state, nil)
+				case "attach":
+					if s.state == Recording {
+						m.attachRecording("test", record.NewGroup(df.Testcase{Name: "test"}, nil, nil, &mocks.TestRepository{}))
+					} else {
+						m.attachVerification("test", verify.NewGroup("test", nil, df.Config{}, nil, &mocks.TestRepository{}))
+					}
+				case "stop":
+					_, err = m.stop("test", s.state)
+				case "end":
+					m.end("test")
+				}
+				if s.status == 0 {
+					assert.NoError(t, err, "step %d: %s %s", i, s.action, s.state)
+				} else if assert.Error(t, err, "step %d: %s %s", i, s.action, s.state) {
+					assert.Equal(t, s.status, transitionStatus(err), "step %d: %s %s", i, s.action, s.state)
+				}
+				assert.Equal(t, s.want, m.state("test"), "step %d: %s %s", i, s.action, s.state)
+			}
+		})
+	}
+}
+
+func TestFailedSession(t *testing.T) {
+	m := newSessionManager()
+	channels := []df.Channel{{Name: "mysql", Format: "mock"}}
+	logs := []df.Log{mocks.NewFailingSQLLog(nil, errors.New("log file vanished"))}
+	group := record.NewGroup(df.Testcase{Name: "failing"}, channels, logs, &mocks.TestRepository{})
+	assert.NoError(t, m.begin("failing", Recording, []string{"mysql"}))
+	assert.NoError(t, group.Start())
+	m.attachRecording("failing", group)
+
+	assert.Eventually(t, func() bool { return m.state("failing") == Failed }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "channel 'mysql': log file vanished", m.all()[0].Error)
+	assert.Equal(t, "channel 'mysql': log file vanished", group.Testcase().Error)
+
+	// failed sessions can't be verified but stopped
+	assert.Error(t, m.begin("failing", Verifying, nil))
+	s, err := m.stop("failing", Recording)
+	assert.NoError(t, err)
+	s.recording.Stop()
+	m.end("failing")
+	assert.Equal(t, Idle, m.state("failing"))
+}
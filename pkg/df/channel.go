@@ -1,8 +1,105 @@
 package df
 
+import "time"
+
+// DefaultGraceTimeout is the default time to wait for log lines that were
+// flushed after a run has been stopped.
+const DefaultGraceTimeout = 2 * time.Second
+
+// Follow modes select how a channel's log file is followed while waiting for
+// new lines. FollowNotify waits for file system events and falls back to
+// polling if events aren't available, FollowPoll checks the file periodically.
+const (
+	FollowNotify = "notify"
+	FollowPoll   = "poll"
+)
+
 type Channel struct {
 	Name     string
 	Log      string
 	Format   string
 	Patterns []string
+
+	// Milliseconds to wait for log lines that were flushed after a run has been
+	// stopped. 0 means DefaultGraceTimeout, a negative value disables waiting.
+	GraceTimeout int `json:"grace_timeout"`
+
+	// Follow mode of the log file: notify (default) or poll.
+	Follow string `json:"follow"`
+
+	// Regex configures the layout of the log file if Format is regex.
+	Regex *RegexFormat `json:"regex,omitempty"`
+
+	// JSONL configures the fields of the log file if Format is jsonl.
+	JSONL *JSONLFormat `json:"jsonl,omitempty"`
+
+	// Proxy configures the HTTP proxy if Format is http-proxy. Channels of
+	// this format have no log file.
+	Proxy *ProxyFormat `json:"proxy,omitempty"`
+
+	// Names of the normalizers applied to the tokens of each statement, e.g.
+	// hibernate, see Normalizer.
+	Normalize []string `json:"normalize,omitempty"`
+}
+
+// RegexFormat describes the layout of log files of the regex format. Line
+// matches the first line of each entry and must contain the named groups
+// timestamp and statement, the group session is optional. Example:
+//
+//	^(?P<timestamp>\S+ \S+) \[(?P<session>\d+)\] (?P<statement>.*)$
+//
+// Lines that match Continuation continue the statement of the previous entry.
+// If Continuation is empty, all lines that don't match Line are continuation
+// lines.
+type RegexFormat struct {
+	Line            string `json:"line"`
+	TimestampLayout string `json:"timestamp_layout"` // Go layout of the timestamp group
+	Timezone        string `json:"timezone"`         // IANA name of the timestamps' zone, default Local
+	Continuation    string `json:"continuation"`
+}
+
+// JSONLFormat describes the fields of log files of the jsonl format, that
+// contain one json object per line. Fields are named by their key or by a path
+// of keys separated by dots for nested objects, e.g. "db.statement". Timestamp
+// and Statement are required. Example:
+//
+//	{"ts":"2024-04-08 12:50:59.123","level":"debug","sql":"select * from job where id=?","args":[5]}
+//
+// Args names an optional array of bind arguments that replace the placeholders
+// ? or $1, $2, ... of the statement.
+type JSONLFormat struct {
+	Timestamp       string `json:"timestamp"`
+	TimestampLayout string `json:"timestamp_layout"` // Go layout, unix or unixmilli, default RFC3339
+	Timezone        string `json:"timezone"`         // IANA name of the timestamps' zone, default Local
+	Statement       string `json:"statement"`
+	Session         string `json:"session"`
+	Args            string `json:"args"`
+}
+
+// ProxyFormat configures the HTTP proxy of channels of the http-proxy format,
+// that records the requests the SUT sends to external services. If Target is
+// set, the proxy forwards all requests to Target, e.g. the SUT calls
+// http://localhost:8090/v1/charges instead of https://api.payment.com/v1/charges.
+// Otherwise the SUT uses the proxy as forward proxy, e.g. by
+// HTTP_PROXY=http://localhost:8090, which can't record HTTPS requests.
+type ProxyFormat struct {
+	Listen  string   `json:"listen"`  // address of the proxy, e.g. localhost:8090
+	Target  string   `json:"target"`  // base URL of the external service
+	Headers []string `json:"headers"` // request headers that are part of the expectations
+}
+
+// Grace returns the time to wait for log lines that were flushed after a run
+// has been stopped.
+func (c Channel) Grace() time.Duration {
+	if c.GraceTimeout == 0 {
+		return DefaultGraceTimeout
+	}
+	return time.Duration(c.GraceTimeout) * time.Millisecond
+}
+
+// ChannelSelection selects a configured channel by its name. Patterns override
+// the channel's configured patterns if not empty.
+type ChannelSelection struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"patterns,omitempty"`
 }
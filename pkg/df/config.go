@@ -7,6 +7,7 @@ package df
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -57,7 +58,7 @@ func NewDefaultConfig() (Config, error) {
 }
 
 // NewConfig creates a new instance given its settings from filename in json
-// format.
+// format. The formats of all channels must be registered, see RegisterFormat.
 func NewConfig(filename string) Config {
 	log.Printf("using config file '%s'", filename)
 	configfile, err := os.Open(filename)
@@ -75,13 +76,92 @@ func NewConfig(filename string) Config {
 	if err := json.Unmarshal(byteValue, &config); err != nil {
 		log.Fatal(err)
 	}
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
 	return config
 }
 
+// SelectChannels resolves selection to the configured channels and applies the
+// selected pattern overrides. An empty selection selects all configured
+// channels.
+func (c Config) SelectChannels(selection []ChannelSelection) ([]Channel, error) {
+	if len(selection) == 0 {
+		return c.Channels, nil
+	}
+
+	var channels []Channel
+	for _, s := range selection {
+		channel, ok := c.Channel(s.Name)
+		if !ok {
+			return nil, fmt.Errorf("channel '%s' does not exist", s.Name)
+		}
+		if len(s.Patterns) > 0 {
+			channel.Patterns = s.Patterns
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// Channel returns the configured channel called name.
+func (c Config) Channel(name string) (Channel, bool) {
+	for _, ch := range c.Channels {
+		if ch.Name == name {
+			return ch, true
+		}
+	}
+	return Channel{}, false
+}
+
 func exists(filename string) bool {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		return false
 	}
 	return true
 }
+
+// Validate checks that all channels use a registered format with valid
+// settings and known normalizers.
+func (c Config) Validate() error {
+	for _, channel := range c.Channels {
+		format, err := LookupFormat(channel.Format)
+		if err != nil {
+			return fmt.Errorf("channel '%s': %w", channel.Name, err)
+		}
+		if channel.Follow != "" && channel.Follow != FollowNotify && channel.Follow != FollowPoll {
+			return fmt.Errorf("channel '%s': unknown follow mode '%s'", channel.Name, channel.Follow)
+		}
+		for _, name := range channel.Normalize {
+			if _, err := LookupNormalizer(name); err != nil {
+				return fmt.Errorf("channel '%s': %w", channel.Name, err)
+			}
+		}
+		if format.Validate == nil {
+			continue
+		}
+		if err := format.Validate(channel); err != nil {
+			return fmt.Errorf("channel '%s': %w", channel.Name, err)
+		}
+	}
+	return nil
+}
+
+// Serve starts the services the configured channels depend on, see
+// Format.Serve.
+func (c Config) Serve() error {
+	for _, channel := range c.Channels {
+		format, err := LookupFormat(channel.Format)
+		if err != nil {
+			return fmt.Errorf("channel '%s': %w", channel.Name, err)
+		}
+		if format.Serve == nil {
+			continue
+		}
+		if err := format.Serve(channel); err != nil {
+			return fmt.Errorf("channel '%s': %w", channel.Name, err)
+		}
+	}
+	return nil
+}
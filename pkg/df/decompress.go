@@ -0,0 +1,18 @@
+package df
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+)
+
+// Decompress returns a reader that decompresses r if its content is gzip
+// compressed. Uncompressed content is read as is.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
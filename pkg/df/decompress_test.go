@@ -0,0 +1,28 @@
+package df
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompress(t *testing.T) {
+	content := "2024-04-08T09:39:15.070009Z	 2549 Query	select * from jobs;\n"
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+
+	for _, r := range []io.Reader{strings.NewReader(content), &compressed} {
+		d, err := Decompress(r)
+		assert.NoError(t, err)
+		actual, err := io.ReadAll(d)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	}
+}
@@ -0,0 +1,43 @@
+package df
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Drain reads the lines a database flushed to log after a run has been stopped
+// and passes them to handle. Drain returns after reading the first line at or
+// after the stop time of timer or when timeout has passed, whatever comes
+// first. Lines without timestamp are passed to handle as well.
+func Drain(l Log, timer Timer, timeout time.Duration, handle func(line string)) error {
+	if timeout <= 0 {
+		return nil
+	}
+	stop := timer.GetStop()
+	if stop.IsZero() {
+		stop = time.Now().UTC()
+	}
+
+	done := make(chan struct{})
+	t := time.AfterFunc(timeout, func() { close(done) })
+	defer t.Stop()
+
+	for {
+		line, err := l.NextLine(done)
+		if err != nil {
+			return err
+		}
+
+		// NextLine returns an empty line if done was closed
+		if line == "" {
+			log.Printf("drain: timeout of %v passed", timeout)
+			return nil
+		}
+
+		handle(line)
+		if ts, err := l.Timestamp(line); err == nil && !ts.Before(stop) {
+			return nil
+		}
+	}
+}
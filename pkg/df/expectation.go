@@ -21,12 +21,22 @@ import (
 // over time and the overall test quality gains.
 type Expectation struct {
 	Uuid      string   `json:"uuid"`
+	Channel   string   `json:"channel"` // name of the channel the expectation was recorded from
 	Tokens    []string `json:"tokens"`
 	Pattern   string
 	Fulfilled bool
 	Verified  int
 
 	IgnoreDiffs []int `json:"ignoreDiffs"` // indizes of tokens allowed to deviate when comparing two Expectations
+
+	// Metadata of the statement the expectation was recorded or last verified
+	// from, e.g. Query_time, Rows_sent and Rows_examined of the mysql-slow
+	// format.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Tokens before normalization if the channel normalized them, see
+	// Normalizer. Original is only used for display.
+	Original []string `json:"original,omitempty"`
 }
 
 // Equal compares e's tokens with the given tokens. The tokens sets are equal if
@@ -84,14 +94,24 @@ func contains[T comparable](values []T, value T) bool {
 	return false
 }
 
+// String returns the original tokens if e was normalized and its tokens
+// otherwise.
 func (e Expectation) String() string {
-	return strings.Join(e.Tokens, " ")
+	return strings.Join(e.display(), " ")
 }
 
 func (e Expectation) Shorten(i int) string {
-	if i >= len(e.Tokens) {
+	tokens := e.display()
+	if i >= len(tokens) {
 		return e.String()
 	}
 
-	return fmt.Sprintf("%s...%s", strings.Join(e.Tokens[0:i/2], " "), strings.Join(e.Tokens[len(e.Tokens)-i/2:], " "))
+	return fmt.Sprintf("%s...%s", strings.Join(tokens[0:i/2], " "), strings.Join(tokens[len(tokens)-i/2:], " "))
+}
+
+func (e Expectation) display() []string {
+	if len(e.Original) > 0 {
+		return e.Original
+	}
+	return e.Tokens
 }
@@ -0,0 +1,89 @@
+package df
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LineBreaks escapes the line breaks of values, thus entries that are made of
+// fields remain on a single line.
+var LineBreaks = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// Flatten returns the fields of the json value v as "path=value" sorted by
+// their path, e.g. {"filter": {"_id": 5}, "limit": 1} becomes ["filter._id=5",
+// "limit=1"] for the empty path. Array elements are addressed by their index.
+// Values other than objects and arrays are formatted by value.
+func Flatten(path string, v any, value func(any) string) []string {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 && path != "" {
+			return []string{path + "={}"}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var fields []string
+		for _, k := range keys {
+			fields = append(fields, Flatten(joinPath(path, k), v[k], value)...)
+		}
+		return fields
+	case []any:
+		if len(v) == 0 {
+			return []string{path + "=[]"}
+		}
+		var fields []string
+		for i, e := range v {
+			fields = append(fields, Flatten(joinPath(path, fmt.Sprint(i)), e, value)...)
+		}
+		return fields
+	default:
+		return []string{path + "=" + value(v)}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Quote returns the field f as single token that is split again by Split.
+// Fields that are empty or contain spaces or single quotes are quoted by ',
+// that are escaped by doubling them.
+func Quote(f string) string {
+	if f != "" && !strings.ContainsAny(f, " '") {
+		return f
+	}
+	return "'" + strings.ReplaceAll(f, "'", "''") + "'"
+}
+
+// Split splits s into the fields separated by spaces, see Quote. Quoted parts
+// of fields are unquoted, thus 'Java Dev' becomes Java Dev and
+// filter.title='Java Dev' becomes filter.title=Java Dev.
+func Split(s string) []string {
+	var fields []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var b strings.Builder
+		quoted := false
+		i := 0
+		for ; i < len(s) && (quoted || s[i] != ' '); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if quoted && i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			quoted = !quoted
+		}
+		fields = append(fields, b.String())
+		s = s[i:]
+	}
+	return fields
+}
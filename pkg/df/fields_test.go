@@ -0,0 +1,33 @@
+package df
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatten(t *testing.T) {
+	var v any
+	assert.NoError(t, json.Unmarshal([]byte(`{"limit": 1, "filter": {"_id": 5, "tags": ["a b"]}, "sort": {}, "hint": []}`), &v))
+	value := func(v any) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	assert.Equal(t, []string{"filter._id=5", `filter.tags.0="a b"`, "hint=[]", "limit=1", "sort={}"}, Flatten("", v, value))
+	assert.Equal(t, []string{"body={}"}, Flatten("body", map[string]any{}, value))
+	assert.Nil(t, Flatten("", map[string]any{}, value))
+}
+
+func TestQuoteAndSplit(t *testing.T) {
+	fields := []string{"SET", "job:5", "Java Dev", "", "it's", "filter.title=O'Reilly"}
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		quoted[i] = Quote(f)
+	}
+	assert.Equal(t, []string{"SET", "job:5", "'Java Dev'", "''", "'it''s'", "'filter.title=O''Reilly'"}, quoted)
+	assert.Equal(t, fields, Split(" SET job:5 'Java Dev' '' 'it''s'  'filter.title=O''Reilly' "))
+
+	// quoted parts of fields are unquoted as well
+	assert.Equal(t, []string{"find", "filter.title=Java Dev", "limit=1"}, Split("find filter.title='Java Dev' limit=1"))
+}
@@ -0,0 +1,32 @@
+package df
+
+import "time"
+
+// FixedTimer bounds the recording period to a fixed time window, e.g. when
+// recording or verifying a finished log. A zero From or To leaves the period
+// open at its start or end.
+type FixedTimer struct {
+	From time.Time
+	To   time.Time
+}
+
+func (t FixedTimer) Start() {
+}
+
+func (t FixedTimer) GetStart() time.Time {
+	return t.From
+}
+
+func (t FixedTimer) Stop() {
+}
+
+func (t FixedTimer) GetStop() time.Time {
+	return t.To
+}
+
+func (t FixedTimer) MatchesRecordingPeriod(ts time.Time) bool {
+	if !t.From.IsZero() && ts.Before(t.From) {
+		return false
+	}
+	return t.To.IsZero() || !ts.After(t.To)
+}
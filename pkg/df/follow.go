@@ -0,0 +1,102 @@
+package df
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// PollInterval is the time to wait for new lines if a log file is polled.
+const PollInterval = 500 * time.Millisecond
+
+// notifyFallback limits the wait for file system events, which may get lost on
+// network file systems.
+const notifyFallback = 2 * time.Second
+
+// waiter waits until a followed log file may contain new lines.
+type waiter interface {
+	// wait blocks until path may contain new lines or done is closed.
+	wait(path string, done chan struct{})
+	close()
+}
+
+// newWaiter creates the waiter of the given follow mode for the file path.
+// Falls back to polling if file system events aren't available.
+func newWaiter(mode string, path string) (waiter, error) {
+	switch mode {
+	case FollowPoll:
+		return poller{}, nil
+	case "", FollowNotify:
+		w, err := newNotifier(path)
+		if err != nil {
+			log.Printf("unable to watch %s, falling back to polling: %v", path, err)
+			return poller{}, nil
+		}
+		return w, nil
+	}
+	return nil, fmt.Errorf("unknown follow mode '%s'", mode)
+}
+
+type poller struct{}
+
+func (p poller) wait(_ string, done chan struct{}) {
+	select {
+	case <-time.After(PollInterval):
+	case <-done:
+	}
+}
+
+func (p poller) close() {}
+
+// notifier waits for file system events of the directory that contains the log
+// file. Watching the directory instead of the file keeps the watch alive when
+// the file is rotated.
+type notifier struct {
+	watcher *fsnotify.Watcher
+}
+
+func newNotifier(path string) (notifier, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return notifier{}, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return notifier{}, err
+	}
+	return notifier{watcher: watcher}, nil
+}
+
+// wait returns on changes of path, on newly created files, which may replace
+// path, or after notifyFallback at the latest.
+func (n notifier) wait(path string, done chan struct{}) {
+	timeout := time.NewTimer(notifyFallback)
+	defer timeout.Stop()
+	for {
+		select {
+		case e, ok := <-n.watcher.Events:
+			if !ok {
+				return
+			}
+			if e.Has(fsnotify.Create) || filepath.Clean(e.Name) == filepath.Clean(path) {
+				return
+			}
+		case err, ok := <-n.watcher.Errors:
+			if ok {
+				log.Errorf("watching %s: %v", path, err)
+			}
+			return
+		case <-timeout.C:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+func (n notifier) close() {
+	_ = n.watcher.Close()
+}
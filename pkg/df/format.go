@@ -0,0 +1,77 @@
+package df
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Format bundles the LogFactory and the Tokenizer of a log format. Channels
+// select their format by its name. Formats register themselves, usually in the
+// init function of their package, thus embedders can provide their own formats:
+//
+//	func init() {
+//		df.RegisterFormat(df.Format{
+//			Name:       "oracle",
+//			LogFactory: func(channel df.Channel) df.LogFactory { return LogFactory{} },
+//			Tokenizer:  Tokenizer{},
+//		})
+//	}
+type Format struct {
+	Name string
+
+	// LogFactory returns the factory that creates the logs of channel.
+	LogFactory func(channel Channel) LogFactory
+
+	Tokenizer Tokenizer
+
+	// Validate checks the format specific settings of channel. May be nil.
+	Validate func(channel Channel) error
+
+	// Serve starts the services channel depends on when the API starts, e.g.
+	// the proxy of http-proxy channels. May be nil.
+	Serve func(channel Channel) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// RegisterFormat makes format available by its name. Panics if a format with
+// the same name was already registered or if format lacks its LogFactory or
+// Tokenizer.
+func RegisterFormat(format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if format.LogFactory == nil || format.Tokenizer == nil {
+		panic(fmt.Sprintf("format '%s' requires LogFactory and Tokenizer", format.Name))
+	}
+	if _, ok := formats[format.Name]; ok {
+		panic(fmt.Sprintf("format '%s' registered twice", format.Name))
+	}
+	formats[format.Name] = format
+}
+
+// LookupFormat returns the format registered as name.
+func LookupFormat(name string) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	format, ok := formats[name]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown format '%s'", name)
+	}
+	return format, nil
+}
+
+// Formats returns the names of all registered formats in alphabetical order.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	var names []string
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
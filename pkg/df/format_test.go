@@ -0,0 +1,47 @@
+package df
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopLogFactory struct{}
+
+func (f nopLogFactory) Create(_ string) (Log, error) { return nil, nil }
+func (f nopLogFactory) Static(_ io.Reader) Log       { return nil }
+
+type nopTokenizer struct{}
+
+func (t nopTokenizer) Tokenize(s string, _ []string) []string { return Tokenize(s) }
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "nop",
+		LogFactory: func(Channel) LogFactory { return nopLogFactory{} },
+		Tokenizer:  nopTokenizer{},
+	})
+}
+
+func TestLookupFormat(t *testing.T) {
+	f, err := LookupFormat("nop")
+	assert.Nil(t, err)
+	assert.Equal(t, nopTokenizer{}, f.Tokenizer)
+	assert.Contains(t, Formats(), "nop")
+
+	_, err = LookupFormat("oracle")
+	assert.NotNil(t, err)
+}
+
+func TestRegisterFormatTwice(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterFormat(Format{Name: "nop", LogFactory: func(Channel) LogFactory { return nopLogFactory{} }, Tokenizer: nopTokenizer{}})
+	})
+}
+
+func TestValidateRejectsUnknownFormats(t *testing.T) {
+	assert.Nil(t, Config{Channels: []Channel{{Name: "db", Format: "nop"}}}.Validate())
+	assert.NotNil(t, Config{Channels: []Channel{{Name: "db", Format: "oracle"}}}.Validate())
+	assert.NotNil(t, Config{Channels: []Channel{{Name: "db", Format: "nop", Follow: "tail"}}}.Validate())
+}
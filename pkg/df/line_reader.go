@@ -0,0 +1,153 @@
+package df
+
+import (
+	"bufio"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// An Assembler assembles the entries returned by LineReader.NextLine from the
+// physical lines of a log, e.g. statements that span several lines.
+type Assembler interface {
+	// Add adds the physical line read from the log. Returns an entry that got
+	// complete by line.
+	Add(line string) (string, bool)
+
+	// Flush returns an entry that is complete without further lines. eof is
+	// true if no further lines are available yet, end is true at the end of a
+	// static log that won't get further lines.
+	Flush(eof bool, end bool) (string, bool)
+}
+
+// Parser is the Assembler of logs whose lines are complete entries. It returns
+// the entry of a line or false if the line is skipped.
+type Parser func(line string) (string, bool)
+
+func (p Parser) Add(line string) (string, bool) {
+	return p(line)
+}
+
+func (p Parser) Flush(_ bool, _ bool) (string, bool) {
+	return "", false
+}
+
+// LineReader reads the physical lines of a followed log file or of a static
+// log. Log formats embed it and assemble their entries by an Assembler.
+type LineReader struct {
+	logfile *LogFile      // followed log file
+	reader  *bufio.Reader // reader of static logs
+}
+
+// NewStaticLineReader creates a reader of the finished log r. Instead of
+// waiting for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLineReader(r io.Reader) LineReader {
+	return LineReader{reader: bufio.NewReader(r)}
+}
+
+// NewLineReader opens the log file name, see OpenLogFile. The log is followed
+// across rotation and truncation according to the follow mode.
+func NewLineReader(name string, follow string, resolve func(name string) (string, error)) (LineReader, error) {
+	logfile, err := OpenLogFile(name, follow, resolve)
+	if err != nil {
+		return LineReader{}, err
+	}
+	return LineReader{logfile: logfile}, nil
+}
+
+// ReadString reads the next line from the static reader or the followed log
+// file.
+func (r LineReader) ReadString() (string, error) {
+	if r.logfile == nil {
+		return r.reader.ReadString('\n')
+	}
+	return r.logfile.ReadString()
+}
+
+// Name returns the name of the log file or an empty string for static logs.
+func (r LineReader) Name() string {
+	if r.logfile == nil {
+		return ""
+	}
+	return r.logfile.Name()
+}
+
+// Tail sets the read cursor of the log file to its end.
+func (r LineReader) Tail() error {
+	log.Printf("tailing %s...", r.Name())
+	defer log.Printf("tailing successful!")
+	for {
+		_, err := r.ReadString()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			} else {
+				return err
+			}
+		}
+	}
+}
+
+func (r LineReader) Close() {
+	if r.logfile == nil {
+		return
+	}
+	err := r.logfile.Close()
+	if err != nil {
+		log.Errorf("unable to close %s: %v", r.logfile.Name(), err)
+		return
+	}
+	log.Printf("%s closed", r.logfile.Name())
+}
+
+// Switches returns the rotations and truncations of the log file.
+func (r LineReader) Switches() []LogSwitch {
+	if r.logfile == nil {
+		return nil
+	}
+	return r.logfile.Switches()
+}
+
+// NextLine returns the next entry assembled by a. Waits until a new entry
+// becomes available or done is closed, static logs return io.EOF at their end
+// instead. Returns with an empty line and a nil error if the done channel was
+// closed.
+func (r LineReader) NextLine(done chan struct{}, a Assembler) (string, error) {
+	for {
+		if s, ok := a.Flush(false, false); ok {
+			return s, nil
+		}
+		select {
+		default:
+			line, err := r.ReadString()
+			if err == io.EOF && r.logfile == nil {
+				// the last line of a finished log may lack its line break
+				if line != "" {
+					if s, ok := a.Add(line); ok {
+						return s, nil
+					}
+				}
+				if s, ok := a.Flush(true, true); ok {
+					return s, nil
+				}
+				return "", io.EOF
+			}
+			if err != nil {
+				if err == io.EOF {
+					if s, ok := a.Flush(true, false); ok {
+						return s, nil
+					}
+					r.logfile.Wait(done)
+					continue
+				}
+				return "", err
+			}
+			if s, ok := a.Add(line); ok {
+				return s, nil
+			}
+		case <-done:
+			log.Printf("nextline: done channel closed")
+			return "", nil
+		}
+	}
+}
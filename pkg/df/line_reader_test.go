@@ -0,0 +1,87 @@
+package df
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// joiner joins all lines read until the end of the lines written so far.
+type joiner struct {
+	lines []string
+	ends  []bool // end argument of each Flush at eof
+}
+
+func (j *joiner) Add(line string) (string, bool) {
+	j.lines = append(j.lines, strings.TrimRight(line, "\n"))
+	return "", false
+}
+
+func (j *joiner) Flush(eof bool, end bool) (string, bool) {
+	if !eof || len(j.lines) == 0 {
+		return "", false
+	}
+	j.ends = append(j.ends, end)
+	s := strings.Join(j.lines, " ")
+	j.lines = nil
+	return s, true
+}
+
+func TestLineReaderParsesLines(t *testing.T) {
+	r := NewStaticLineReader(strings.NewReader("select 1\n# comment\nselect 2"))
+	skipComments := Parser(func(line string) (string, bool) {
+		return line, !strings.HasPrefix(line, "#")
+	})
+
+	line, err := r.NextLine(nil, skipComments)
+	assert.Nil(t, err)
+	assert.Equal(t, "select 1\n", line)
+	line, err = r.NextLine(nil, skipComments)
+	assert.Nil(t, err)
+	assert.Equal(t, "select 2", line)
+	_, err = r.NextLine(nil, skipComments)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLineReaderFlushesAtEndOfStaticLog(t *testing.T) {
+	r := NewStaticLineReader(strings.NewReader("select 1\nfrom dual\n"))
+	j := &joiner{}
+
+	line, err := r.NextLine(nil, j)
+	assert.Nil(t, err)
+	assert.Equal(t, "select 1 from dual", line)
+	assert.Equal(t, []bool{true}, j.ends)
+	_, err = r.NextLine(nil, j)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLineReaderFlushesAtEndOfFollowedLog(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "select 1\nfrom dual\n")
+	r, err := NewLineReader(name, FollowPoll, nil)
+	assert.Nil(t, err)
+	defer r.Close()
+	j := &joiner{}
+
+	line, err := r.NextLine(make(chan struct{}), j)
+	assert.Nil(t, err)
+	assert.Equal(t, "select 1 from dual", line)
+	assert.Equal(t, []bool{false}, j.ends)
+}
+
+func TestLineReaderReturnsIfDone(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "")
+	r, err := NewLineReader(name, FollowPoll, nil)
+	assert.Nil(t, err)
+	defer r.Close()
+	done := make(chan struct{})
+	close(done)
+
+	line, err := r.NextLine(done, &joiner{})
+	assert.Nil(t, err)
+	assert.Equal(t, "", line)
+}
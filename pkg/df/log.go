@@ -1,6 +1,7 @@
 package df
 
 import (
+	"regexp"
 	"time"
 )
 
@@ -12,3 +13,34 @@ type Log interface {
 	Close()
 	Tail() error
 }
+
+// A PrecisionLog is a Log whose timestamps have a coarser resolution than the
+// clock of the recording period, e.g. whole seconds. Precision returns the
+// resolution of the timestamps.
+type PrecisionLog interface {
+	Log
+	Precision() time.Duration
+}
+
+// Precision returns the resolution of the timestamps of l if l is a
+// PrecisionLog and 0 otherwise.
+func Precision(l Log) time.Duration {
+	if pl, ok := l.(PrecisionLog); ok {
+		return pl.Precision()
+	}
+	return 0
+}
+
+// fractionRegex matches the fractional seconds of a time layout, e.g. .000 or
+// ,999999.
+var fractionRegex = regexp.MustCompile(`[.,](0+|9+)(?:[^0-9]|$)`)
+
+// LayoutPrecision returns the resolution of timestamps written in the Go time
+// layout, that is a second if the layout has no fractional seconds and 0
+// otherwise.
+func LayoutPrecision(layout string) time.Duration {
+	if fractionRegex.MatchString(layout) {
+		return 0
+	}
+	return time.Second
+}
@@ -1,5 +1,11 @@
 package df
 
+import "io"
+
 type LogFactory interface {
 	Create(filename string) (Log, error)
+
+	// Static creates a log that reads the finished log r, e.g. a log file kept
+	// as CI artifact. NextLine returns io.EOF at the end of r.
+	Static(r io.Reader) Log
 }
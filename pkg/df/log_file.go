@@ -0,0 +1,195 @@
+package df
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogSwitch describes the switch of a followed log file to a new file or to the
+// beginning of a truncated file.
+type LogSwitch struct {
+	Channel string    `json:"channel,omitempty"`
+	Time    time.Time `json:"time"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Reason  string    `json:"reason"` // rotated, truncated or new file
+}
+
+// Follower is implemented by logs that follow their log file across rotation
+// and truncation.
+type Follower interface {
+	Switches() []LogSwitch
+}
+
+// LogFile reads the lines of a log file that is written by a database server.
+// The file is followed across rotation and truncation: when the end of the
+// current file is reached, LogFile checks whether the file name refers to a new
+// file or whether the file shrank, and reopens it. Lines that are not yet
+// terminated by \n are kept until the rest of the line was written. Wait blocks
+// until new lines may be available according to the follow mode.
+type LogFile struct {
+	name    string                            // configured file name
+	resolve func(name string) (string, error) // maps name to the current file, may be nil
+	waiter  waiter
+
+	mu       sync.Mutex
+	path     string // path of the open file
+	file     *os.File
+	reader   *bufio.Reader
+	offset   int64  // bytes read from file
+	partial  string // unterminated line read so far
+	switches []LogSwitch
+}
+
+// OpenLogFile opens the log file name that is followed according to the follow
+// mode, see FollowNotify and FollowPoll. If resolve is not nil it maps name to
+// the current log file, e.g. the newest file of a series of timestamped files.
+// resolve is called again on every end of file in order to pick up new files.
+// The names of the series must sort in the order the files are created, the log
+// file only switches to names sorting after the current one.
+func OpenLogFile(name string, follow string, resolve func(name string) (string, error)) (*LogFile, error) {
+	path := name
+	if resolve != nil {
+		var err error
+		if path, err = resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	f := &LogFile{name: name, resolve: resolve}
+	if err := f.open(path); err != nil {
+		return nil, err
+	}
+	w, err := newWaiter(follow, path)
+	if err != nil {
+		_ = f.file.Close()
+		return nil, err
+	}
+	f.waiter = w
+	return f, nil
+}
+
+func (f *LogFile) open(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	f.path = path
+	f.file = file
+	f.reader = bufio.NewReader(file)
+	f.offset = 0
+	f.partial = ""
+	return nil
+}
+
+// Name returns the path of the currently open file.
+func (f *LogFile) Name() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.path
+}
+
+// ReadString returns the next line terminated by \n. Returns io.EOF if no
+// complete line is available yet.
+func (f *LogFile) ReadString() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	line, err := f.readString()
+	if err != io.EOF {
+		return line, err
+	}
+	if !f.follow() {
+		return "", io.EOF
+	}
+	return f.readString()
+}
+
+func (f *LogFile) readString() (string, error) {
+	line, err := f.reader.ReadString('\n')
+	f.offset += int64(len(line))
+	if err != nil {
+		f.partial += line
+		return "", err
+	}
+	line = f.partial + line
+	f.partial = ""
+	return line, nil
+}
+
+// follow checks at the end of the current file whether the log was rotated or
+// truncated and switches to the new file or to its beginning. Returns true if a
+// switch happened.
+func (f *LogFile) follow() bool {
+	path := f.path
+	reason := "rotated"
+	if f.resolve != nil {
+		if p, err := f.resolve(f.name); err == nil && p > f.path {
+			path = p
+			reason = "new file"
+		}
+	}
+	next, err := os.Stat(path)
+	if err != nil {
+		// rotated but not yet recreated
+		return false
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return false
+	}
+
+	if os.SameFile(current, next) {
+		if next.Size() >= f.offset {
+			return false
+		}
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			log.Errorf("unable to rewind truncated %s: %v", f.path, err)
+			return false
+		}
+		f.reader.Reset(f.file)
+		f.offset = 0
+		f.partial = ""
+		f.record(f.path, "truncated")
+		return true
+	}
+
+	old := f.file
+	from := f.path
+	if err := f.open(path); err != nil {
+		log.Errorf("unable to open %s: %v", path, err)
+		return false
+	}
+	_ = old.Close()
+	f.record(from, reason)
+	return true
+}
+
+func (f *LogFile) record(from string, reason string) {
+	s := LogSwitch{Time: time.Now(), From: from, To: f.path, Reason: reason}
+	log.Printf("log switched from %s to %s: %s", s.From, s.To, s.Reason)
+	f.switches = append(f.switches, s)
+}
+
+// Wait blocks until the log file may contain new lines or done is closed.
+func (f *LogFile) Wait(done chan struct{}) {
+	f.waiter.wait(f.Name(), done)
+}
+
+// Switches returns the switches that happened since the log file was opened.
+func (f *LogFile) Switches() []LogSwitch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]LogSwitch(nil), f.switches...)
+}
+
+// Close closes the currently open file.
+func (f *LogFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waiter.close()
+	return f.file.Close()
+}
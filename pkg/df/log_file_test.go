@@ -0,0 +1,152 @@
+package df
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFileKeepsUnterminatedLines(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "select 1")
+	f, err := OpenLogFile(name, FollowPoll, nil)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	_, err = f.ReadString()
+	assert.Equal(t, io.EOF, err)
+	appendTo(t, name, " from dual\n")
+	assertLine(t, f, "select 1 from dual\n")
+}
+
+func TestLogFileFollowsRotation(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "select 1\n")
+	f, err := OpenLogFile(name, FollowPoll, nil)
+	assert.Nil(t, err)
+	defer f.Close()
+	assertLine(t, f, "select 1\n")
+
+	assert.Nil(t, os.Rename(name, name+".1"))
+	_, err = f.ReadString()
+	assert.Equal(t, io.EOF, err)
+	write(t, name, "select 2\n")
+	assertLine(t, f, "select 2\n")
+	assert.Len(t, f.Switches(), 1)
+	assert.Equal(t, "rotated", f.Switches()[0].Reason)
+}
+
+func TestLogFileFollowsTruncation(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "select 1\nselect 2\n")
+	f, err := OpenLogFile(name, FollowPoll, nil)
+	assert.Nil(t, err)
+	defer f.Close()
+	assertLine(t, f, "select 1\n")
+	assertLine(t, f, "select 2\n")
+
+	assert.Nil(t, os.Truncate(name, 0))
+	appendTo(t, name, "select 3\n")
+	assertLine(t, f, "select 3\n")
+	assert.Len(t, f.Switches(), 1)
+	assert.Equal(t, "truncated", f.Switches()[0].Reason)
+}
+
+func TestLogFilePicksUpNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "postgresql-1.log")
+	write(t, current, "select 1\n")
+	resolve := func(string) (string, error) { return current, nil }
+	f, err := OpenLogFile(filepath.Join(dir, "postgresql-*.log"), FollowPoll, resolve)
+	assert.Nil(t, err)
+	defer f.Close()
+	assertLine(t, f, "select 1\n")
+
+	current = filepath.Join(dir, "postgresql-2.log")
+	write(t, current, "select 2\n")
+	assertLine(t, f, "select 2\n")
+	assert.Equal(t, current, f.Name())
+	assert.Equal(t, "new file", f.Switches()[0].Reason)
+}
+
+func TestLogFileSwitchesOnlyToLaterFiles(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "postgresql-2.log")
+	write(t, current, "select 2\n")
+	resolve := func(string) (string, error) { return current, nil }
+	f, err := OpenLogFile(filepath.Join(dir, "postgresql-*.log"), FollowPoll, resolve)
+	assert.Nil(t, err)
+	defer f.Close()
+	assertLine(t, f, "select 2\n")
+
+	current = filepath.Join(dir, "postgresql-1.log")
+	write(t, current, "select 1\n")
+	_, err = f.ReadString()
+	assert.Equal(t, io.EOF, err)
+	assert.Empty(t, f.Switches())
+}
+
+func assertLine(t *testing.T, f *LogFile, expected string) {
+	line, err := f.ReadString()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, line)
+}
+
+func write(t *testing.T, name string, s string) {
+	if err := os.WriteFile(name, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendTo(t *testing.T, name string, s string) {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogFileWaitsForNotification(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "")
+	f, err := OpenLogFile(name, FollowNotify, nil)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		appendTo(t, name, "select 1\n")
+	}()
+	start := time.Now()
+	f.Wait(nil)
+	assert.Less(t, time.Since(start), notifyFallback)
+	assertLine(t, f, "select 1\n")
+}
+
+func TestLogFileWaitHonorsDone(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "")
+	f, err := OpenLogFile(name, FollowPoll, nil)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	done := make(chan struct{})
+	close(done)
+	start := time.Now()
+	f.Wait(done)
+	assert.Less(t, time.Since(start), PollInterval)
+}
+
+func TestOpenLogFileRejectsUnknownFollowMode(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	write(t, name, "")
+	_, err := OpenLogFile(name, "tail", nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,164 @@
+package df
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A Normalizer canonicalizes tokens that change between test runs for reasons
+// other than the behavior under test, e.g. generated alias names. Channels
+// select normalizers by their name, see Channel.Normalize.
+type Normalizer interface {
+	Normalize(tokens []string) []string
+}
+
+// normalizers contains the normalizers by their name.
+var normalizers = map[string]Normalizer{
+	"hibernate": HibernateAliases{},
+}
+
+// LookupNormalizer returns the normalizer called name.
+func LookupNormalizer(name string) (Normalizer, error) {
+	n, ok := normalizers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown normalizer '%s'", name)
+	}
+	return n, nil
+}
+
+// A NormalizingTokenizer applies its normalizers to the tokens of the embedded
+// Tokenizer. The tokens before normalization are kept for display, see
+// Original.
+type NormalizingTokenizer struct {
+	Tokenizer
+	Normalizers []Normalizer
+}
+
+// NewTokenizer returns t extended by the normalizers selected by channel. Returns
+// t if channel selects no normalizers.
+func NewTokenizer(t Tokenizer, channel Channel) (Tokenizer, error) {
+	if len(channel.Normalize) == 0 {
+		return t, nil
+	}
+	nt := NormalizingTokenizer{Tokenizer: t}
+	for _, name := range channel.Normalize {
+		n, err := LookupNormalizer(name)
+		if err != nil {
+			return nil, err
+		}
+		nt.Normalizers = append(nt.Normalizers, n)
+	}
+	return nt, nil
+}
+
+// Tokenize tokenizes s by the embedded Tokenizer and normalizes the tokens.
+func (t NormalizingTokenizer) Tokenize(s string, patterns []string) []string {
+	tokens := t.Tokenizer.Tokenize(s, patterns)
+	for _, n := range t.Normalizers {
+		tokens = n.Normalize(tokens)
+	}
+	return tokens
+}
+
+// Metadata returns the metadata of the embedded Tokenizer.
+func (t NormalizingTokenizer) Metadata(s string) map[string]string {
+	return Metadata(t.Tokenizer, s)
+}
+
+// Statement returns the statement of the embedded Tokenizer.
+func (t NormalizingTokenizer) Statement(s string) string {
+	return Statement(t.Tokenizer, s)
+}
+
+// Original returns the tokens of s before normalization if t is a
+// NormalizingTokenizer and the normalizers changed them, nil otherwise.
+func Original(t Tokenizer, s string, patterns []string) []string {
+	nt, ok := t.(NormalizingTokenizer)
+	if !ok {
+		return nil
+	}
+	original := nt.Tokenizer.Tokenize(s, patterns)
+	if strings.Join(original, " ") == strings.Join(nt.Tokenize(s, patterns), " ") {
+		return nil
+	}
+	return original
+}
+
+// hibernateAlias matches the aliases generated by Hibernate 5 for tables, e.g.
+// job0_, and columns, e.g. descript2_0_, as well as the table aliases of
+// Hibernate 6, e.g. j1_0. The first submatch contains the generated suffix of
+// Hibernate 5 aliases.
+const hibernateAlias = `(?:[a-z][a-z0-9_]*?(\d+_(?:\d+_)*)|[a-z]+\d+_\d+)`
+
+var (
+	// hibernateAliasRegex matches tokens that consist of an alias.
+	hibernateAliasRegex = regexp.MustCompile(`^` + hibernateAlias + `$`)
+
+	// hibernateQualifierRegex matches aliases that qualify a column, e.g. job0_.
+	// in job0_.id=application1_.job_id.
+	hibernateQualifierRegex = regexp.MustCompile(`\b` + hibernateAlias + `\.`)
+)
+
+// HibernateAliases replaces the table and column aliases generated by
+// Hibernate by t1, t2, ... and c1, c2, ... in the order of their first
+// appearance. Example: "select job0_.id as id1_0_ from job job0_" becomes
+// "select t1.id as c1 from job t1". Aliases are replaced at identifier
+// positions only, that is after "as", after the tables of the from clause and
+// in front of qualified columns, thus values like 'abc1_' are kept.
+type HibernateAliases struct {
+}
+
+func (h HibernateAliases) Normalize(tokens []string) []string {
+	aliases := make(map[string]string)
+	var tables, columns int
+	rename := func(alias string) string {
+		if a, ok := aliases[alias]; ok {
+			return a
+		}
+		suffix := hibernateAliasRegex.FindStringSubmatch(alias)[1]
+		if strings.Count(suffix, "_") > 1 {
+			columns++
+			aliases[alias] = fmt.Sprintf("c%d", columns)
+		} else {
+			tables++
+			aliases[alias] = fmt.Sprintf("t%d", tables)
+		}
+		return aliases[alias]
+	}
+
+	normalized := make([]string, len(tokens))
+	from := false // token is part of a from clause
+	for i, token := range tokens {
+		switch strings.ToLower(token) {
+		case "from", "join":
+			from = true
+		case "select", "where", "on", "set", "values", "group", "order", "having", "union":
+			from = false
+		}
+		if alias, comma := strings.CutSuffix(token, ","); isAliasPosition(tokens, i, from) && hibernateAliasRegex.MatchString(alias) {
+			normalized[i] = rename(alias)
+			if comma {
+				normalized[i] += ","
+			}
+			continue
+		}
+		normalized[i] = hibernateQualifierRegex.ReplaceAllStringFunc(token, func(qualifier string) string {
+			return rename(strings.TrimSuffix(qualifier, ".")) + "."
+		})
+	}
+	return normalized
+}
+
+// isAliasPosition returns true if the token i follows "as" or the name of a
+// table in the from clause.
+func isAliasPosition(tokens []string, i int, from bool) bool {
+	if i > 0 && strings.EqualFold(tokens[i-1], "as") {
+		return true
+	}
+	if !from || i < 2 {
+		return false
+	}
+	before := strings.ToLower(tokens[i-2])
+	return before == "from" || before == "join" || strings.HasSuffix(before, ",")
+}
@@ -0,0 +1,47 @@
+package df
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHibernateAliases(t *testing.T) {
+	tokens := Tokenize("select job0_.id as id1_0_, job0_.description as descript2_0_, application1_.job_id as job_id3_1_0_ from job job0_ left outer join application application1_ on job0_.id=application1_.job_id")
+	actual := HibernateAliases{}.Normalize(tokens)
+	assert.Equal(t, Tokenize("select t1.id as c1, t1.description as c2, t2.job_id as c3 from job t1 left outer join application t2 on t1.id=t2.job_id"), actual)
+	assert.Equal(t, "job0_.id", tokens[1])
+}
+
+func TestHibernate6Aliases(t *testing.T) {
+	actual := HibernateAliases{}.Normalize(Tokenize("select j1_0.id,j1_0.publish_trials from job j1_0 where j1_0.id=?"))
+	assert.Equal(t, Tokenize("select t1.id,t1.publish_trials from job t1 where t1.id=?"), actual)
+}
+
+func TestHibernateAliasesKeepValues(t *testing.T) {
+	actual := HibernateAliases{}.Normalize(Tokenize("select job0_.id as id1_0_ from job job0_, application application1_ where job0_.title='abc1_' and application1_.state='open'"))
+	assert.Equal(t, Tokenize("select t1.id as c1 from job t1, application t2 where t1.title='abc1_' and t2.state='open'"), actual)
+
+	tokens := Tokenize("insert into job (title, tag, id) values ('abc1_', 'x y1_', 5)")
+	assert.Equal(t, tokens, HibernateAliases{}.Normalize(tokens))
+}
+
+func TestNormalizingTokenizer(t *testing.T) {
+	tokenizer, err := NewTokenizer(nopTokenizer{}, Channel{Normalize: []string{"hibernate"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"select", "t1.id", "from", "job", "t1"}, tokenizer.Tokenize("select job0_.id from job job0_", nil))
+	assert.Equal(t, []string{"select", "job0_.id", "from", "job", "job0_"}, Original(tokenizer, "select job0_.id from job job0_", nil))
+	assert.Nil(t, Original(tokenizer, "select id from job", nil))
+
+	tokenizer, err = NewTokenizer(nopTokenizer{}, Channel{})
+	assert.NoError(t, err)
+	assert.Equal(t, nopTokenizer{}, tokenizer)
+
+	_, err = NewTokenizer(nopTokenizer{}, Channel{Normalize: []string{"unknown"}})
+	assert.Error(t, err)
+}
+
+func TestValidateRejectsUnknownNormalizers(t *testing.T) {
+	assert.Nil(t, Config{Channels: []Channel{{Name: "db", Format: "nop", Normalize: []string{"hibernate"}}}}.Validate())
+	assert.NotNil(t, Config{Channels: []Channel{{Name: "db", Format: "nop", Normalize: []string{"eclipselink"}}}}.Validate())
+}
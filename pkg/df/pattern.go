@@ -43,3 +43,15 @@ func (p Pattern) matchesExclude(s string) bool {
 func (p Pattern) matches(s string) bool {
 	return p.matchesInclude(s) && !p.matchesExclude(s)
 }
+
+// CutPrefix cuts everything in front of the first matching pattern from s, e.g.
+// the timestamp and thread id of a log entry.
+func CutPrefix(s string, patterns []string) string {
+	for _, p := range patterns {
+		idx := strings.Index(s, NewPattern(p).Include)
+		if idx > -1 {
+			return s[idx:]
+		}
+	}
+	return s
+}
@@ -26,3 +26,9 @@ func TestMatchesPattern(t *testing.T) {
 		})
 	}
 }
+
+func TestCutPrefix(t *testing.T) {
+	s := "2024-04-08T09:39:15.070009Z\t2549 Query\tinsert into job values (1)"
+	assert.Equal(t, "insert into job values (1)", CutPrefix(s, patterns))
+	assert.Equal(t, "select 1", CutPrefix("select 1", patterns))
+}
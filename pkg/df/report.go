@@ -7,14 +7,71 @@ import (
 )
 
 type Report struct {
-	Testname               string        `json:"testname"`
-	LastExecution          time.Time     `json:"last_execution"`
-	Verifications          int           `json:"verifications"`
-	Expectations           int           `json:"expectations"`
-	Fulfilled              int           `json:"fulfilled"`
-	Unfulfilled            []Expectation `json:"unfulfilled,omitempty"`
-	VerificationMean       float32       `json:"verification_mean"`
-	AdditionalExpectations []string      `json:"additional_expectations,omitempty"`
+	Testname               string          `json:"testname"`
+	LastExecution          time.Time       `json:"last_execution"`
+	Verifications          int             `json:"verifications"`
+	Expectations           int             `json:"expectations"`
+	Fulfilled              int             `json:"fulfilled"`
+	Unfulfilled            []Expectation   `json:"unfulfilled,omitempty"`
+	VerificationMean       float32         `json:"verification_mean"`
+	AdditionalExpectations []string        `json:"additional_expectations,omitempty"`
+	Channels               []ChannelReport `json:"channels,omitempty"`
+}
+
+// ChannelReport breaks the results of a Report down to a single channel.
+type ChannelReport struct {
+	Channel      string        `json:"channel"`
+	Expectations int           `json:"expectations"`
+	Fulfilled    int           `json:"fulfilled"`
+	Unfulfilled  []Expectation `json:"unfulfilled,omitempty"`
+}
+
+// NewReport creates a Report of the verification results of tc.
+func NewReport(testname string, tc Testcase) Report {
+	fulfilled := 0
+	verifiedSum := 0
+	for _, e := range tc.Expectations {
+		verifiedSum += e.Verified
+		if e.Fulfilled {
+			fulfilled = fulfilled + 1
+		}
+	}
+	report := Report{
+		Testname:         testname,
+		LastExecution:    time.Now(),
+		Expectations:     len(tc.Expectations),
+		Verifications:    tc.Verifications,
+		Fulfilled:        fulfilled,
+		VerificationMean: verificationMean(float32(verifiedSum), float32(len(tc.Expectations))),
+	}
+	for _, e := range tc.Expectations {
+		if !e.Fulfilled {
+			report.Unfulfilled = append(report.Unfulfilled, e)
+		}
+	}
+	for _, e := range tc.AdditionalExpectations {
+		report.AdditionalExpectations = append(report.AdditionalExpectations, e.Shorten(6))
+	}
+	for _, channel := range tc.ChannelNames() {
+		cr := ChannelReport{Channel: channel}
+		for _, e := range tc.ExpectationsOf(channel) {
+			cr.Expectations = cr.Expectations + 1
+			if e.Fulfilled {
+				cr.Fulfilled = cr.Fulfilled + 1
+			} else {
+				cr.Unfulfilled = append(cr.Unfulfilled, e)
+			}
+		}
+		report.Channels = append(report.Channels, cr)
+	}
+	return report
+}
+
+func verificationMean(sum, expectationCount float32) float32 {
+	if expectationCount > 0 {
+		return sum / expectationCount
+	}
+	return 0
 }
 
 func (r Report) String() string {
@@ -25,14 +82,24 @@ func (r Report) String() string {
 		"Expectations: %d\n"+
 		"Fulfilled: %d\n"+
 		"Verification mean: %f\n"+
-		"Unfulfilled: %s\n",
+		"Unfulfilled: %s\n"+
+		"%s",
 		r.Testname,
 		r.LastExecution.Format(time.DateTime),
 		r.Verifications,
 		r.Expectations,
 		r.Fulfilled,
 		r.VerificationMean,
-		strings.Join(toString(r.Unfulfilled), "\n"))
+		strings.Join(toString(r.Unfulfilled), "\n"),
+		strings.Join(channelsToString(r.Channels), ""))
+}
+
+func channelsToString(channels []ChannelReport) []string {
+	var result []string
+	for _, c := range channels {
+		result = append(result, fmt.Sprintf("Channel %s: %d of %d fulfilled\n", c.Channel, c.Fulfilled, c.Expectations))
+	}
+	return result
 }
 
 func toString(e []Expectation) []string {
@@ -6,11 +6,19 @@ import (
 
 type Testcase struct {
 	Name          string        `json:"name"`
+	Description   string        `json:"description,omitempty"`
 	Running       bool          `json:"running"`
 	Verifications int           `json:"verifications"`
 	Expectations  []Expectation `json:"expectation"`
 	LastExecution time.Time     `json:"last_execution"`
 
+	// Channels the testcase was recorded with. Verifications reuse this
+	// selection unless the caller selects channels explicitly.
+	Channels []ChannelSelection `json:"channels,omitempty"`
+
+	// Error that aborted the currently running recording or verification
+	Error string `json:"error,omitempty"`
+
 	// Expectations, that match one of the patterns but didn't match one of the
 	// expected expectations
 	AdditionalExpectations []Expectation `json:"additional_expectations"`
@@ -37,3 +45,26 @@ func (t Testcase) Unfulfilled() []Expectation {
 	}
 	return unfulfilled
 }
+
+// ChannelNames returns the distinct channel names of the expectations in order
+// of their first appearance.
+func (t Testcase) ChannelNames() []string {
+	var channels []string
+	for _, e := range t.Expectations {
+		if !contains(channels, e.Channel) {
+			channels = append(channels, e.Channel)
+		}
+	}
+	return channels
+}
+
+// ExpectationsOf returns the expectations that were recorded from channel.
+func (t Testcase) ExpectationsOf(channel string) []Expectation {
+	var expectations []Expectation
+	for _, e := range t.Expectations {
+		if e.Channel == channel {
+			expectations = append(expectations, e)
+		}
+	}
+	return expectations
+}
@@ -2,8 +2,25 @@ package df
 
 import "time"
 
+// Timer bounds the recording period of a run. The period starts with Start and
+// ends with Stop. Runs that haven't been stopped yet have an open end.
 type Timer interface {
 	Start()
 	GetStart() time.Time
+	Stop()
+	GetStop() time.Time
 	MatchesRecordingPeriod(ts time.Time) bool
 }
+
+// InRecordingPeriod returns true if the timestamp ts of log l falls in the
+// recording period of t. The start of the period is compared at the precision
+// of the log, thus statements that are logged in the second the recording
+// started match if the log has timestamps of whole seconds.
+func InRecordingPeriod(t Timer, l Log, ts time.Time) bool {
+	start := t.GetStart()
+	if p := Precision(l); p > 0 && ts.Before(start) && !ts.Before(start.Truncate(p)) {
+		// ts was logged in the time unit the period started
+		ts = start
+	}
+	return t.MatchesRecordingPeriod(ts)
+}
@@ -13,3 +13,38 @@ package df
 type Tokenizer interface {
 	Tokenize(s string, patterns []string) []string
 }
+
+// A MetadataTokenizer is a Tokenizer of log formats that log metadata of their
+// statements, e.g. execution times or row counts. Metadata returns the metadata
+// of the raw string s or nil.
+type MetadataTokenizer interface {
+	Tokenizer
+	Metadata(s string) map[string]string
+}
+
+// Metadata returns the metadata of s if t is a MetadataTokenizer and nil
+// otherwise.
+func Metadata(t Tokenizer, s string) map[string]string {
+	if mt, ok := t.(MetadataTokenizer); ok {
+		return mt.Metadata(s)
+	}
+	return nil
+}
+
+// A StatementTokenizer is a Tokenizer of log formats whose lines contain fields
+// besides the statement that may match patterns by accident, e.g. the
+// application name of json records. Statement returns the statement of the raw
+// string s.
+type StatementTokenizer interface {
+	Tokenizer
+	Statement(s string) string
+}
+
+// Statement returns the statement of s if t is a StatementTokenizer and s
+// otherwise. Patterns are matched against the statement.
+func Statement(t Tokenizer, s string) string {
+	if st, ok := t.(StatementTokenizer); ok {
+		return st.Statement(s)
+	}
+	return s
+}
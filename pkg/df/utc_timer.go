@@ -1,19 +1,48 @@
 package df
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
+// UTCTimer bounds the recording period by the current UTC time. Runners stop
+// the timer while their recorder or verifier reads it, thus start and stop are
+// guarded by a mutex.
 type UTCTimer struct {
+	mu    sync.Mutex
 	start time.Time
+	stop  time.Time
 }
 
 func (t *UTCTimer) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.start = time.Now().UTC()
 }
 
 func (t *UTCTimer) GetStart() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.start
 }
 
+func (t *UTCTimer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stop = time.Now().UTC()
+}
+
+func (t *UTCTimer) GetStop() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stop
+}
+
 func (t *UTCTimer) MatchesRecordingPeriod(ts time.Time) bool {
-	return ts.Equal(t.start) || ts.After(t.start)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ts.Before(t.start) {
+		return false
+	}
+	return t.stop.IsZero() || !ts.After(t.stop)
 }
@@ -0,0 +1,45 @@
+package df
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesRecordingPeriod(t *testing.T) {
+	timer := &UTCTimer{}
+	timer.Start()
+	assert.False(t, timer.MatchesRecordingPeriod(timer.GetStart().Add(-time.Millisecond)))
+	assert.True(t, timer.MatchesRecordingPeriod(timer.GetStart()))
+	assert.True(t, timer.MatchesRecordingPeriod(timer.GetStart().Add(time.Hour)))
+
+	timer.Stop()
+	assert.True(t, timer.MatchesRecordingPeriod(timer.GetStop()))
+	assert.False(t, timer.MatchesRecordingPeriod(timer.GetStop().Add(time.Millisecond)))
+}
+
+// secondsLog is a log with timestamps of whole seconds.
+type secondsLog struct{}
+
+func (l secondsLog) Timestamp(string) (time.Time, error)    { return time.Time{}, nil }
+func (l secondsLog) NextLine(chan struct{}) (string, error) { return "", nil }
+func (l secondsLog) Close()                                 {}
+func (l secondsLog) Tail() error                            { return nil }
+func (l secondsLog) Precision() time.Duration               { return time.Second }
+
+func TestInRecordingPeriodComparesAtPrecisionOfLog(t *testing.T) {
+	timer := FixedTimer{From: time.Date(2024, 4, 2, 6, 38, 5, 500000000, time.UTC)}
+	startSecond := time.Date(2024, 4, 2, 6, 38, 5, 0, time.UTC)
+	assert.True(t, InRecordingPeriod(timer, secondsLog{}, startSecond))
+	assert.False(t, InRecordingPeriod(timer, secondsLog{}, startSecond.Add(-time.Second)))
+	assert.False(t, InRecordingPeriod(timer, nil, startSecond))
+}
+
+func TestLayoutPrecision(t *testing.T) {
+	assert.Equal(t, time.Second, LayoutPrecision(time.DateTime))
+	assert.Equal(t, time.Second, LayoutPrecision("02.01.2006 15:04:05"))
+	assert.Equal(t, time.Duration(0), LayoutPrecision("2006-01-02 15:04:05.000"))
+	assert.Equal(t, time.Duration(0), LayoutPrecision("2006-01-02 15:04:05,999999"))
+	assert.Equal(t, time.Duration(0), LayoutPrecision("15:04:05.0 02.01.2006"))
+}
@@ -0,0 +1,18 @@
+// Package formats registers all log formats that come with datafrog. Programs
+// import it for its side effects:
+//
+//	import _ "github.com/rwirdemann/datafrog/pkg/formats"
+package formats
+
+import (
+	_ "github.com/rwirdemann/datafrog/pkg/access"
+	_ "github.com/rwirdemann/datafrog/pkg/jsonl"
+	_ "github.com/rwirdemann/datafrog/pkg/mariadb"
+	_ "github.com/rwirdemann/datafrog/pkg/mongodb"
+	_ "github.com/rwirdemann/datafrog/pkg/mysql"
+	_ "github.com/rwirdemann/datafrog/pkg/p6spy"
+	_ "github.com/rwirdemann/datafrog/pkg/postgres"
+	_ "github.com/rwirdemann/datafrog/pkg/proxy"
+	_ "github.com/rwirdemann/datafrog/pkg/redis"
+	_ "github.com/rwirdemann/datafrog/pkg/regex"
+)
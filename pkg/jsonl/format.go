@@ -0,0 +1,223 @@
+// Package jsonl implements the jsonl log format, which reads application logs
+// written as json lines. The fields of timestamp, statement, session and bind
+// arguments are configured by a df.JSONLFormat. It allows to record and verify
+// the statements of applications if the log of their database isn't reachable.
+package jsonl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name: "jsonl",
+		LogFactory: func(channel df.Channel) df.LogFactory {
+			return LogFactory{Format: channel.JSONL, Follow: channel.Follow}
+		},
+		Tokenizer: Tokenizer{},
+		Validate: func(channel df.Channel) error {
+			_, err := compile(channel.JSONL)
+			return err
+		},
+	})
+}
+
+// format is the validated df.JSONLFormat.
+type format struct {
+	df.JSONLFormat
+	location *time.Location
+}
+
+func compile(f *df.JSONLFormat) (format, error) {
+	if f == nil {
+		return format{}, errors.New("jsonl format requires the channel setting jsonl")
+	}
+	if f.Timestamp == "" {
+		return format{}, errors.New("timestamp field is required")
+	}
+	if f.Statement == "" {
+		return format{}, errors.New("statement field is required")
+	}
+	location := time.Local
+	if f.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(f.Timezone); err != nil {
+			return format{}, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	return format{JSONLFormat: *f, location: location}, nil
+}
+
+// parse returns the json line as single line, see Log. Returns false if line
+// isn't a json object or lacks timestamp or statement, e.g. log entries of
+// domain events.
+func (f format) parse(line string) (string, bool) {
+	var fields map[string]any
+	d := json.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return "", false
+	}
+	statement, ok := lookup(fields, f.Statement).(string)
+	if !ok || strings.TrimSpace(statement) == "" {
+		return "", false
+	}
+	t, err := f.timestamp(lookup(fields, f.Timestamp))
+	if err != nil {
+		return "", false
+	}
+	session := ""
+	if f.Session != "" {
+		if v := lookup(fields, f.Session); v != nil {
+			session = fmt.Sprint(v)
+		}
+	}
+	if f.Args != "" {
+		if args, ok := lookup(fields, f.Args).([]any); ok {
+			statement = bind(statement, args)
+		}
+	}
+	return t.UTC().Format(time.RFC3339Nano) + "\t" + session + "\t" + singleLine(statement) + "\n", true
+}
+
+// precision returns the resolution of the timestamps of the configured layout.
+func (f format) precision() time.Duration {
+	switch f.TimestampLayout {
+	case "unix":
+		return time.Second
+	case "unixmilli":
+		return time.Millisecond
+	case "":
+		return 0
+	}
+	return df.LayoutPrecision(f.TimestampLayout)
+}
+
+// timestamp parses the timestamp field v according to the configured layout.
+func (f format) timestamp(v any) (time.Time, error) {
+	switch f.TimestampLayout {
+	case "unix", "unixmilli":
+		n, ok := v.(json.Number)
+		if !ok {
+			return time.Time{}, errors.New("timestamp is no number")
+		}
+		if i, err := n.Int64(); err == nil {
+			if f.TimestampLayout == "unixmilli" {
+				return time.UnixMilli(i), nil
+			}
+			return time.Unix(i, 0), nil
+		}
+		// fractional timestamps are exact to the microsecond
+		seconds, err := n.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if f.TimestampLayout == "unixmilli" {
+			seconds = seconds / 1000
+		}
+		return time.UnixMicro(int64(math.Round(seconds * 1e6))), nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, errors.New("timestamp is no string")
+	}
+	layout := f.TimestampLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	return time.ParseInLocation(layout, s, f.location)
+}
+
+// lookup returns the field path of fields, e.g. "db.statement".
+func lookup(fields map[string]any, path string) any {
+	var v any = fields
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+var (
+	numberedRegex    = regexp.MustCompile(`\$\d+|'(?:[^']|'')*'`)
+	placeholderRegex = regexp.MustCompile(`\?|'(?:[^']|'')*'`)
+)
+
+// bind replaces the placeholders of statement outside of string literals by
+// args. Numbered placeholders $1, $2, ... are replaced by the argument of their
+// number, otherwise each ? is replaced by the next argument.
+func bind(statement string, args []any) string {
+	if numbered := numberedRegex.FindAllString(statement, -1); slices.ContainsFunc(numbered, isNumbered) {
+		return numberedRegex.ReplaceAllStringFunc(statement, func(p string) string {
+			if !isNumbered(p) {
+				return p
+			}
+			i, _ := strconv.Atoi(p[1:])
+			if i < 1 || i > len(args) {
+				return p
+			}
+			return literal(args[i-1])
+		})
+	}
+	next := 0
+	return placeholderRegex.ReplaceAllStringFunc(statement, func(p string) string {
+		if p != "?" || next >= len(args) {
+			return p
+		}
+		next++
+		return literal(args[next-1])
+	})
+}
+
+func isNumbered(p string) bool {
+	return strings.HasPrefix(p, "$")
+}
+
+// literal formats the argument v as SQL literal.
+func literal(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	default:
+		b, _ := json.Marshal(v)
+		return "'" + strings.ReplaceAll(string(b), "'", "''") + "'"
+	}
+}
+
+// singleLine joins the lines of s.
+func singleLine(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	var parts []string
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			parts = append(parts, l)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// statement returns the statement of a line returned by Log.NextLine.
+func statement(s string) string {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return s
+	}
+	return fields[2]
+}
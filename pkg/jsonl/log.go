@@ -0,0 +1,71 @@
+package jsonl
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Log reads log files of the jsonl format. Json lines with statement are
+// returned as single line that contains the timestamp in UTC, the session and
+// the statement with bound arguments:
+//
+//	2024-04-08T09:39:15.07Z	2549	select * from job where id=5
+type Log struct {
+	df.LineReader
+	format format // fields of the json lines
+	err    error  // invalid format of a static log
+}
+
+// NewStaticLog creates a log of format f that reads the finished log r.
+func NewStaticLog(r io.Reader, f *df.JSONLFormat) (Log, error) {
+	format, err := compile(f)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: df.NewStaticLineReader(r), format: format}, nil
+}
+
+// NewJSONLLog opens the log logfileName of format f. The log is followed across
+// rotation and truncation according to the follow mode.
+func NewJSONLLog(logfileName string, f *df.JSONLFormat, follow string) (Log, error) {
+	format, err := compile(f)
+	if err != nil {
+		return Log{}, err
+	}
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader, format: format}, nil
+}
+
+// Timestamp returns the timestamp of a line returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	ts, _, _ := strings.Cut(s, "\t")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	return t, nil
+}
+
+// Precision returns the resolution of the timestamps of the configured layout,
+// e.g. a second for unix timestamps.
+func (m Log) Precision() time.Duration {
+	return m.format.precision()
+}
+
+// NextLine returns the statement of the next json line. Waits until a new
+// statement becomes available or done is closed, static logs return io.EOF at
+// their end instead. Returns with an empty line and a nil error if the done
+// channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.LineReader.NextLine(done, df.Parser(m.format.parse))
+}
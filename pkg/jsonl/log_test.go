@@ -0,0 +1,94 @@
+package jsonl
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+var app = &df.JSONLFormat{
+	Timestamp:       "ts",
+	TimestampLayout: "2006-01-02 15:04:05.000",
+	Timezone:        "Europe/Berlin",
+	Statement:       "sql",
+	Session:         "ctx.session",
+	Args:            "args",
+}
+
+func TestNextLine(t *testing.T) {
+	l, err := NewStaticLog(strings.NewReader(`{"ts":"2024-04-08 11:39:15.070","level":"debug","sql":"select * from job where id=? and title <> '?'","args":[5],"ctx":{"session":42}}`+"\n"+
+		`{"ts":"2024-04-08 11:39:15.080","level":"info","event":"JobPublished","job":5}`+"\n"+
+		"no json\n"+
+		`{"ts":"2024-04-08 11:39:16.000","sql":"insert into job (title, tags, published)\n  values ($1, $2, $3)","args":["O'Reilly",["go"],null]}`), app)
+	assert.Nil(t, err)
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.07Z\t42\tselect * from job where id=5 and title <> '?'\n", line)
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:16Z\t\tinsert into job (title, tags, published) values ('O''Reilly', '[\"go\"]', NULL)\n", line)
+	_, err = l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestUnixTimestamps(t *testing.T) {
+	f := &df.JSONLFormat{Timestamp: "time", TimestampLayout: "unixmilli", Statement: "query"}
+	l, err := NewStaticLog(strings.NewReader(`{"time":1712569155070,"query":"select 1"}`), f)
+	assert.Nil(t, err)
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.07Z\t\tselect 1\n", line)
+}
+
+func TestUnixTimestampsKeepPrecision(t *testing.T) {
+	// integers are exact beyond the precision of float64
+	f := &df.JSONLFormat{Timestamp: "time", TimestampLayout: "unixmilli", Statement: "query"}
+	l, err := NewStaticLog(strings.NewReader(`{"time":9007199254740993,"query":"select 1"}`+"\n"+
+		`{"time":1712569155070.5,"query":"select 2"}`), f)
+	assert.Nil(t, err)
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, time.UnixMilli(9007199254740993).UTC().Format(time.RFC3339Nano)+"\t\tselect 1\n", line)
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.0705Z\t\tselect 2\n", line)
+	assert.Equal(t, time.Millisecond, l.Precision())
+
+	f = &df.JSONLFormat{Timestamp: "time", TimestampLayout: "unix", Statement: "query"}
+	l, err = NewStaticLog(strings.NewReader(`{"time":1712569155,"query":"select 1"}`), f)
+	assert.Nil(t, err)
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15Z\t\tselect 1\n", line)
+	ts, err := l.Timestamp(line)
+	assert.Nil(t, err)
+	start := time.Date(2024, 4, 8, 9, 39, 15, 500000000, time.UTC)
+	assert.True(t, df.InRecordingPeriod(df.FixedTimer{From: start}, l, ts))
+	assert.False(t, df.InRecordingPeriod(df.FixedTimer{From: start.Add(time.Second)}, l, ts))
+}
+
+func TestBindSkipsStringLiterals(t *testing.T) {
+	assert.Equal(t, "update job set title='$1 off', price=5 where id=7", bind("update job set title='$1 off', price=$1 where id=$2", []any{json.Number("5"), json.Number("7")}))
+	assert.Equal(t, "select 'it''s ?' from job where id=5", bind("select 'it''s ?' from job where id=?", []any{json.Number("5")}))
+	assert.Equal(t, "select '$1' from job where id=5", bind("select '$1' from job where id=?", []any{json.Number("5")}))
+}
+
+func TestValidate(t *testing.T) {
+	format, err := df.LookupFormat("jsonl")
+	assert.Nil(t, err)
+	assert.Nil(t, format.Validate(df.Channel{JSONL: app}))
+	assert.NotNil(t, format.Validate(df.Channel{}))
+	assert.NotNil(t, format.Validate(df.Channel{JSONL: &df.JSONLFormat{Timestamp: "ts"}}))
+	assert.NotNil(t, format.Validate(df.Channel{JSONL: &df.JSONLFormat{Timestamp: "ts", Statement: "sql", Timezone: "Mars/Olympus"}}))
+}
+
+func TestStaticLogWithInvalidFormat(t *testing.T) {
+	l := LogFactory{}.Static(strings.NewReader(""))
+	_, err := l.NextLine(nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,14 @@
+package jsonl
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type Tokenizer struct {
+}
+
+// Tokenize cuts timestamp and session from s. The statement is split by spaces
+// into single tokens afterward.
+func (t Tokenizer) Tokenize(s string, patterns []string) []string {
+	return df.Tokenize(df.CutPrefix(statement(s), patterns))
+}
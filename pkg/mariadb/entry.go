@@ -0,0 +1,70 @@
+package mariadb
+
+import (
+	"regexp"
+	"strings"
+)
+
+// headerRegex matches the first line of a general log entry. MariaDB logs the
+// timestamp only on the first entry of each second:
+//
+//	240402  6:38:05	     12 Query	select * from job
+//			     12 Query	select * from application
+//
+// Submatches are the timestamp, the thread id, the command type and the
+// argument of the command. Entries without timestamp start with two tabs, thus
+// continuation lines like "   5 AND status" don't start a new entry.
+var headerRegex = regexp.MustCompile(`^(?:(\d{6}\s+\d{1,2}:\d{2}:\d{2})|\t)\t *(\d+) ([A-Z][A-Za-z ]*?)(?:\t(.*))?$`)
+
+// statement assembles an entry from its header line and the continuation lines
+// of statements that span several lines. Entries without timestamp get the
+// timestamp of the previous entry.
+type statement struct {
+	line      string // entry with timestamp joined with its continuation lines
+	timestamp string // last timestamp seen
+}
+
+// Add adds the physical line read from the log. Returns the previous entry if
+// line starts a new entry. Lines before the first timestamp, e.g. the server's
+// startup banner, are dropped.
+func (s *statement) Add(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", false
+	}
+	if m := headerRegex.FindStringSubmatch(line); m != nil {
+		previous := s.flush()
+		if m[1] != "" {
+			s.timestamp = m[1]
+		}
+		if s.timestamp != "" {
+			s.line = s.timestamp + "\t" + m[2] + " " + m[3] + "\t" + m[4]
+		}
+		return previous, previous != ""
+	}
+	if s.line != "" {
+		s.line = s.line + " " + strings.TrimLeft(line, "\t ")
+	}
+	return "", false
+}
+
+// Flush returns the current entry at the end of the lines written so far.
+func (s *statement) Flush(eof bool, _ bool) (string, bool) {
+	// entries are written at once, the last one is complete
+	if !eof {
+		return "", false
+	}
+	line := s.flush()
+	return line, line != ""
+}
+
+// flush returns the current entry and resets it. Returns an empty string if
+// there is no entry.
+func (s *statement) flush() string {
+	if s.line == "" {
+		return ""
+	}
+	line := s.line + "\n"
+	s.line = ""
+	return line
+}
@@ -0,0 +1,91 @@
+package mariadb
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log reads the general query log of MariaDB. Entries are returned with the
+// timestamp of the previous entry if MariaDB logged none:
+//
+//	240402  6:38:05	12 Query	select * from job
+type Log struct {
+	df.LineReader
+	current *statement // entry assembled from the lines read so far
+}
+
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r), current: &statement{}}
+}
+
+// NewMariaDBLog opens the general query log logfileName. The log is followed
+// across rotation and truncation according to the follow mode.
+func NewMariaDBLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader, current: &statement{}}, nil
+}
+
+// Tail sets the read cursor of the log file to its end.
+func (m Log) Tail() error {
+	log.Printf("tailing %s...", m.Name())
+	defer log.Printf("tailing successful!")
+	for {
+		line, err := m.ReadString()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			} else {
+				return err
+			}
+		}
+
+		// keep the timestamp for the entries of the current second
+		m.current.Add(line)
+		m.current.flush()
+	}
+}
+
+var timestampRegex = regexp.MustCompile(`^(\d{6})\s+(\d{1,2}):(\d{2}):(\d{2})`)
+
+// Timestamp returns the timestamp of s in UTC. MariaDB logs timestamps in the
+// local time of the server, that is expected to be the local time of datafrog.
+// Timestamps have a resolution of one second.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	ts := timestampRegex.FindStringSubmatch(s)
+	if ts == nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	hour := ts[2]
+	if len(hour) == 1 {
+		hour = "0" + hour
+	}
+	t, err := time.ParseInLocation("060102 15:04:05", ts[1]+" "+hour+":"+ts[3]+":"+ts[4], time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	return t.UTC(), nil
+}
+
+// Precision returns the resolution of the timestamps of MariaDB.
+func (m Log) Precision() time.Duration {
+	return time.Second
+}
+
+// NextLine returns the next entry of the log file including thread id and
+// command type. Lines without header continue the statement of the previous
+// entry and are joined with it. Waits until a new entry becomes available or
+// done is closed, static logs return io.EOF at their end instead. Returns with
+// an empty line and a nil error if the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, m.current)
+}
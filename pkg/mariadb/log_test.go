@@ -0,0 +1,85 @@
+package mariadb
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCarriesTimestampForward(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("/usr/sbin/mariadbd, Version: 11.3.2-MariaDB-1:11.3.2+maria~ubu2204-log (mariadb.org binary distribution). started with:\n" +
+		"Time		    Id Command	Argument\n" +
+		"240402  6:38:05	     12 Query	select * from job\n" +
+		"		     12 Query	select *\n" +
+		"  from application\n" +
+		"240402 16:38:06	     12 Quit	\n"))
+	assert.Equal(t, "240402  6:38:05\t12 Query\tselect * from job\n", nextLine(t, l))
+	assert.Equal(t, "240402  6:38:05\t12 Query\tselect * from application\n", nextLine(t, l))
+	assert.Equal(t, "240402 16:38:06\t12 Quit\t\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestJoinsContinuationLinesStartingWithNumbers(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("240402  6:38:05\t     12 Query\tselect * from job where id =\n" +
+		"   5 AND status\n" +
+		"  = 'open'\n" +
+		"\t\t     12 Quit\t\n"))
+	assert.Equal(t, "240402  6:38:05\t12 Query\tselect * from job where id = 5 AND status = 'open'\n", nextLine(t, l))
+	assert.Equal(t, "240402  6:38:05\t12 Quit\t\n", nextLine(t, l))
+}
+
+func TestTailKeepsTimestampOfCurrentSecond(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "general.log")
+	if err := os.WriteFile(name, []byte("240402  6:38:05\t     12 Query\tselect * from job\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewMariaDBLog(name, df.FollowPoll)
+	assert.Nil(t, err)
+	defer l.Close()
+	assert.Nil(t, l.Tail())
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteString("\t\t     12 Query\tselect * from application\n")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+	line, err := l.NextLine(make(chan struct{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "240402  6:38:05\t12 Query\tselect * from application\n", line)
+}
+
+func TestTimestamp(t *testing.T) {
+	actual, err := Log{}.Timestamp("240402  6:38:05\t12 Query\tselect * from job")
+	assert.Nil(t, err)
+	expected := time.Date(2024, 4, 2, 6, 38, 5, 0, time.Local).UTC()
+	assert.Equal(t, expected, actual)
+}
+
+func TestMatchesStatementOfStartSecond(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("240402  6:38:05\t12 Query\tselect * from job\n"))
+	ts, err := l.Timestamp(nextLine(t, l))
+	assert.Nil(t, err)
+	start := time.Date(2024, 4, 2, 6, 38, 5, 500000000, time.Local).UTC()
+	assert.True(t, df.InRecordingPeriod(df.FixedTimer{From: start}, l, ts))
+	assert.False(t, df.InRecordingPeriod(df.FixedTimer{From: start.Add(time.Second)}, l, ts))
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenizer{}.Tokenize("240402  6:38:05\t12 Query\tselect * from job where title='Java Dev'\n", []string{"select"})
+	assert.Equal(t, []string{"select", "*", "from", "job", "where", "title=Java Dev"}, tokens)
+}
+
+func nextLine(t *testing.T, l Log) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
@@ -0,0 +1,20 @@
+package mariadb
+
+import (
+	"strings"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type Tokenizer struct {
+}
+
+// Tokenize cuts timestamp, thread id and command type from s. The cleaned
+// statement is split by spaces into single tokens afterward.
+func (m Tokenizer) Tokenize(s string, patterns []string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if h := headerRegex.FindStringSubmatch(s); h != nil {
+		s = h[4]
+	}
+	return df.Tokenize(df.CutPrefix(s, patterns))
+}
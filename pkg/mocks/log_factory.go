@@ -1,6 +1,10 @@
 package mocks
 
-import "github.com/rwirdemann/datafrog/pkg/df"
+import (
+	"io"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
 
 type LogFactory struct {
 }
@@ -8,3 +12,23 @@ type LogFactory struct {
 func (f LogFactory) Create(filename string) (df.Log, error) {
 	return &SQLLog{}, nil
 }
+
+func (f LogFactory) Static(r io.Reader) df.Log {
+	return &SQLLog{}
+}
+
+// Tokenizer splits statements by spaces.
+type Tokenizer struct {
+}
+
+func (t Tokenizer) Tokenize(s string, _ []string) []string {
+	return df.Tokenize(s)
+}
+
+// Format is the log format "mock" whose logs are empty SQLLogs. Tests register
+// it by df.RegisterFormat(mocks.Format).
+var Format = df.Format{
+	Name:       "mock",
+	LogFactory: func(df.Channel) df.LogFactory { return LogFactory{} },
+	Tokenizer:  Tokenizer{},
+}
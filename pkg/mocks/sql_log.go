@@ -10,6 +10,8 @@ type SQLLog struct {
 	logs        []string
 	index       int
 	doneChannel chan struct{} // close this channel to notify verification loop to stop
+	err         error         // returned by NextLine after all logs have been read
+	Closed      bool          // set by Close
 }
 
 func (l *SQLLog) Tail() error {
@@ -20,6 +22,12 @@ func NewMemSQLLog(logs []string, doneChannel chan struct{}) *SQLLog {
 	return &SQLLog{logs: logs, index: 0, doneChannel: doneChannel}
 }
 
+// NewFailingSQLLog creates a SQLLog that fails with err after all logs have
+// been read.
+func NewFailingSQLLog(logs []string, err error) *SQLLog {
+	return &SQLLog{logs: logs, index: 0, err: err}
+}
+
 func (l *SQLLog) Timestamp(s string) (time.Time, error) {
 	t, err := df.Timestamp(s, "[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}\\.[0-9]{6}Z", time.RFC3339Nano)
 	if err != nil {
@@ -30,7 +38,7 @@ func (l *SQLLog) Timestamp(s string) (time.Time, error) {
 
 func (l *SQLLog) NextLine(done chan struct{}) (string, error) {
 	if l.index >= len(l.logs) {
-		return "", nil
+		return "", l.err
 	}
 	line := l.logs[l.index]
 	l.index = l.index + 1
@@ -41,5 +49,5 @@ func (l *SQLLog) NextLine(done chan struct{}) (string, error) {
 }
 
 func (l *SQLLog) Close() {
-
+	l.Closed = true
 }
@@ -2,8 +2,11 @@ package mocks
 
 import "time"
 
+// Timer matches all timestamps up to End. Timer has an open end if End is not
+// set.
 type Timer struct {
 	start time.Time
+	End   time.Time
 }
 
 func (t Timer) Start() {
@@ -13,6 +16,13 @@ func (t Timer) GetStart() time.Time {
 	return t.start
 }
 
+func (t Timer) Stop() {
+}
+
+func (t Timer) GetStop() time.Time {
+	return t.End
+}
+
 func (t Timer) MatchesRecordingPeriod(ts time.Time) bool {
-	return true
+	return t.End.IsZero() || !ts.After(t.End)
 }
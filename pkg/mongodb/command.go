@@ -0,0 +1,172 @@
+package mongodb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// logEntry holds the fields of a mongod log entry used by datafrog.
+type logEntry struct {
+	T struct {
+		Date string `json:"$date"`
+	} `json:"t"`
+	Ctx  string `json:"ctx"`
+	Msg  string `json:"msg"`
+	Attr struct {
+		Type    string          `json:"type"`
+		NS      string          `json:"ns"`
+		Command json.RawMessage `json:"command"`
+	} `json:"attr"`
+}
+
+// commands contains the commands that are returned by Log.NextLine. Other
+// commands, like hello or getMore, are skipped.
+var commands = map[string]bool{
+	"find": true, "insert": true, "update": true, "delete": true, "aggregate": true,
+	"findAndModify": true, "count": true, "distinct": true,
+}
+
+// ignoredFields are command fields that describe the session or the driver
+// instead of the command, they differ between runs.
+var ignoredFields = map[string]bool{
+	"lsid": true, "$clusterTime": true, "$db": true, "txnNumber": true, "autocommit": true,
+	"startTransaction": true, "$readPreference": true, "readConcern": true, "writeConcern": true,
+	"$client": true, "$configTime": true, "$topologyTime": true, "shardVersion": true,
+	"databaseVersion": true, "mayBypassWriteBlocking": true, "apiVersion": true,
+}
+
+// metadataFields are the metrics of slow query entries that are kept as
+// metadata.
+var metadataFields = []string{"durationMillis", "keysExamined", "docsExamined", "nreturned", "nMatched", "nModified", "ndeleted", "ninserted"}
+
+// parseLogEntry returns the command of the "Slow query" entry line as single
+// line, see Log. Returns false if line contains no supported command.
+func parseLogEntry(line string) (string, bool) {
+	var e logEntry
+	d := json.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&e); err != nil || e.Msg != "Slow query" || len(e.Attr.Command) == 0 {
+		return "", false
+	}
+	t, err := time.Parse(time.RFC3339Nano, e.T.Date)
+	if err != nil {
+		return "", false
+	}
+
+	// update and delete statements of write commands are logged as entries of
+	// type update and remove with the statement as command
+	name := ""
+	switch e.Attr.Type {
+	case "update":
+		name = "update"
+	case "remove":
+		name = "delete"
+	case "command":
+		if name, err = firstKey(e.Attr.Command); err != nil {
+			return "", false
+		}
+	}
+	if !commands[name] {
+		return "", false
+	}
+
+	var command map[string]any
+	d = json.NewDecoder(strings.NewReader(string(e.Attr.Command)))
+	d.UseNumber()
+	if err := d.Decode(&command); err != nil {
+		return "", false
+	}
+	ns := e.Attr.NS
+	if ns == "" {
+		ns = fmt.Sprintf("%v.%v", command["$db"], command[name])
+	}
+	if e.Attr.Type == "command" {
+		delete(command, name)
+	}
+	fields := []string{name, ns}
+	for k := range ignoredFields {
+		delete(command, k)
+	}
+	fields = append(fields, df.Flatten("", command, value)...)
+
+	metadata := []string{e.Ctx}
+	var metrics struct {
+		Attr map[string]any `json:"attr"`
+	}
+	d = json.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&metrics); err == nil {
+		for _, k := range metadataFields {
+			if v, ok := metrics.Attr[k].(json.Number); ok {
+				metadata = append(metadata, k+"="+v.String())
+			}
+		}
+	}
+	return t.UTC().Format(time.RFC3339Nano) + "\t" + strings.Join(metadata, " ") + "\t" + strings.Join(fields, " ") + "\n", true
+}
+
+// firstKey returns the first key of the json object s, that is the name of a
+// command.
+func firstKey(s json.RawMessage) (string, error) {
+	d := json.NewDecoder(strings.NewReader(string(s)))
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return "", errors.New("command is no object")
+	}
+	t, err := d.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := t.(string)
+	if !ok {
+		return "", errors.New("command is empty")
+	}
+	return key, nil
+}
+
+// value formats the value v of a field, see df.Flatten. Strings are quoted by
+// ', that are escaped by doubling them, thus strings with spaces remain single
+// tokens, see df.Split. Line breaks are escaped.
+func value(v any) string {
+	switch v := v.(type) {
+	case string:
+		return "'" + strings.ReplaceAll(df.LineBreaks.Replace(v), "'", "''") + "'"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Command is a single command returned by Log.NextLine.
+type Command struct {
+	Time      time.Time
+	Context   string            // connection of the command, e.g. conn12
+	Metadata  map[string]string // metrics of the command, e.g. durationMillis
+	Statement string            // command name, namespace and fields
+}
+
+// ParseCommand parses a command returned by Log.NextLine.
+func ParseCommand(s string) (Command, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return Command{}, errors.New("string contains no command")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Command{}, errors.New("string contains no valid Timestamp")
+	}
+	c := Command{Time: t, Metadata: make(map[string]string), Statement: fields[2]}
+	for i, m := range strings.Fields(fields[1]) {
+		if k, v, ok := strings.Cut(m, "="); ok {
+			c.Metadata[k] = v
+		} else if i == 0 {
+			c.Context = m
+		}
+	}
+	return c, nil
+}
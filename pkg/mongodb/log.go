@@ -0,0 +1,57 @@
+// Package mongodb implements the mongodb format, which reads the commands of
+// the structured JSON log of mongod (MongoDB 4.4+). Commands are logged as
+// "Slow query" entries, log all of them by setting the profiling level 2 or the
+// slowms threshold to -1:
+//
+//	db.setProfilingLevel(0, { slowms: -1 })
+package mongodb
+
+import (
+	"io"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Log reads the commands find, insert, update, delete, aggregate,
+// findAndModify, count and distinct from a mongod log. Each command is returned
+// as single line that contains the time in UTC, the connection, the command's
+// metrics and the canonical command: its name, the namespace and its fields
+// sorted by their path:
+//
+//	2024-04-08T10:50:59.123Z	conn12 durationMillis=0 docsExamined=1 nreturned=1	find jobs.job filter._id=5 limit=1 singleBatch=true
+type Log struct {
+	df.LineReader
+}
+
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r)}
+}
+
+// NewMongoDBLog opens the log logfileName. The log is followed across rotation
+// and truncation according to the follow mode.
+func NewMongoDBLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader}, nil
+}
+
+// Timestamp returns the time of a command returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	c, err := ParseCommand(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.Time, nil
+}
+
+// NextLine returns the next command. Waits until a new command becomes
+// available or done is closed, static logs return io.EOF at their end instead.
+// Returns with an empty line and a nil error if the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, df.Parser(parseLogEntry))
+}
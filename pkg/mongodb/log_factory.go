@@ -0,0 +1,20 @@
+package mongodb
+
+import (
+	"io"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type LogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f LogFactory) Create(filename string) (df.Log, error) {
+	log, err := NewMongoDBLog(filename, f.Follow)
+	return log, err
+}
+
+func (f LogFactory) Static(r io.Reader) df.Log {
+	return NewStaticLog(r)
+}
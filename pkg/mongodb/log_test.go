@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+const mongodLog = `{"t":{"$date":"2024-04-08T12:50:58.000+02:00"},"s":"I","c":"NETWORK","id":22943,"ctx":"listener","msg":"Connection accepted","attr":{"remote":"127.0.0.1:53412","connectionId":12}}
+{"t":{"$date":"2024-04-08T12:50:59.123+02:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"jobs.job","command":{"find":"job","filter":{"_id":5,"title":"Java Dev"},"limit":1,"singleBatch":true,"lsid":{"id":{"$uuid":"6a3c"}},"$clusterTime":{"clusterTime":{"$timestamp":{"t":1712573459,"i":1}}},"$db":"jobs"},"planSummary":"IDHACK","keysExamined":1,"docsExamined":1,"nreturned":1,"durationMillis":0}}
+{"t":{"$date":"2024-04-08T12:50:59.200+02:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"admin.$cmd","command":{"hello":1,"$db":"admin"},"durationMillis":0}}
+{"t":{"$date":"2024-04-08T12:51:00.000+02:00"},"s":"I","c":"WRITE","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"update","ns":"jobs.job","command":{"q":{"_id":5},"u":{"$set":{"tags":["go","mongo"]}},"multi":false,"upsert":false},"nMatched":1,"nModified":1,"durationMillis":1}}
+{"t":{"$date":"2024-04-08T12:51:01.000+02:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"jobs.job","command":{"aggregate":"job","pipeline":[{"$match":{"status":"open"}}],"cursor":{},"$db":"jobs"},"durationMillis":2}}`
+
+func TestNextLine(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(mongodLog))
+	assert.Equal(t, "2024-04-08T10:50:59.123Z\tconn12 durationMillis=0 keysExamined=1 docsExamined=1 nreturned=1\tfind jobs.job filter._id=5 filter.title='Java Dev' limit=1 singleBatch=true\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-08T10:51:00Z\tconn12 durationMillis=1 nMatched=1 nModified=1\tupdate jobs.job multi=false q._id=5 u.$set.tags.0='go' u.$set.tags.1='mongo' upsert=false\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-08T10:51:01Z\tconn12 durationMillis=2\taggregate jobs.job cursor={} pipeline.0.$match.status='open'\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTokenize(t *testing.T) {
+	s := "2024-04-08T10:50:59.123Z\tconn12 durationMillis=0 nreturned=1\tfind jobs.job filter._id=5 filter.title='Java Dev' limit=1\n"
+	matches, pattern := df.MatchesPattern([]string{"insert", "jobs.job"}, s)
+	assert.True(t, matches)
+	tokens := Tokenizer{}.Tokenize(s, []string{pattern})
+	assert.Equal(t, []string{"find", "jobs.job", "filter._id=5", "filter.title=Java Dev", "limit=1"}, tokens)
+	assert.Equal(t, map[string]string{"durationMillis": "0", "nreturned": "1"}, Tokenizer{}.Metadata(s))
+
+	diff, err := df.Expectation{Tokens: tokens}.Diff(Tokenizer{}.Tokenize(strings.Replace(s, "_id=5", "_id=6", 1), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, diff)
+}
+
+func TestTokenizeQuotes(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(`{"t":{"$date":"2024-04-08T12:50:59.123+02:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"jobs.job","command":{"find":"job","filter":{"title":"O'Reilly Dev","publisher":"''"},"$db":"jobs"},"durationMillis":0}}`))
+	s := nextLine(t, l)
+	assert.Equal(t, "2024-04-08T10:50:59.123Z\tconn12 durationMillis=0\tfind jobs.job filter.publisher='''''' filter.title='O''Reilly Dev'\n", s)
+	assert.Equal(t, []string{"find", "jobs.job", "filter.publisher=''", "filter.title=O'Reilly Dev"}, Tokenizer{}.Tokenize(s, nil))
+}
+
+func TestTimestamp(t *testing.T) {
+	actual, err := Log{}.Timestamp("2024-04-08T10:50:59.123Z\tconn12\tfind jobs.job limit=1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 8, 10, 50, 59, 123000000, time.UTC), actual)
+}
+
+func TestEscapesLineBreaksAndSkipsTruncatedLines(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(`{"t":{"$date":"2024-04-08T12:50:59.123+02:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"jobs.job","command":{"find":"job"` + "\n" +
+		`{"t":{"$date":"2024-04-08T12:51:00.000+02:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"jobs.job","command":{"insert":"job","documents":[{"_id":7,"description":"Java\nDev"}],"$db":"jobs"},"durationMillis":1}}`))
+	assert.Equal(t, "2024-04-08T10:51:00Z\tconn12 durationMillis=1\tinsert jobs.job documents.0._id=7 documents.0.description='Java\\nDev'\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func nextLine(t *testing.T, l Log) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
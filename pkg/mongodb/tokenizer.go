@@ -0,0 +1,28 @@
+package mongodb
+
+import "github.com/rwirdemann/datafrog/pkg/df"
+
+// Tokenizer tokenizes the commands returned by Log. The tokens contain the
+// command name, the namespace and one token per field, e.g. ["find", "jobs.job",
+// "filter._id=5", "limit=1"]. Thus fields that differ between runs are learned
+// as ignored diffs. Unlike SQL tokenizers the command isn't cut at the
+// matching pattern, patterns may match the collection and the command name
+// is kept anyway.
+type Tokenizer struct {
+}
+
+func (t Tokenizer) Tokenize(s string, _ []string) []string {
+	if c, err := ParseCommand(s); err == nil {
+		s = c.Statement
+	}
+	return df.Split(s)
+}
+
+// Metadata returns the metrics of s, e.g. durationMillis and docsExamined.
+func (t Tokenizer) Metadata(s string) map[string]string {
+	c, err := ParseCommand(s)
+	if err != nil || len(c.Metadata) == 0 {
+		return nil
+	}
+	return c.Metadata
+}
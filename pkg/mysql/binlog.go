@@ -0,0 +1,174 @@
+package mysql
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Binlog reads row based binary logs decoded to text by:
+//
+//	mysqlbinlog --base64-output=DECODE-ROWS -v
+//
+// Each changed row is returned as single line that contains the time in UTC,
+// the operation, the table and the row as statement-like text:
+//
+//	2024-04-08T10:50:59Z	INSERT jobs.job	insert into jobs.job set @1=2 @2='World'
+//	2024-04-08T10:51:00Z	UPDATE jobs.job	update jobs.job where @1=2 @2='World' set @1=2 @2='Hello'
+//	2024-04-08T10:51:01Z	DELETE jobs.job	delete from jobs.job where @1=2 @2='Hello'
+type Binlog struct {
+	df.LineReader
+	current *rowEvent // row assembled from the lines read so far
+}
+
+// NewStaticBinlog creates a log that reads the finished mysqlbinlog output r.
+func NewStaticBinlog(r io.Reader) Binlog {
+	return Binlog{LineReader: df.NewStaticLineReader(r), current: &rowEvent{}}
+}
+
+// NewBinlog opens logfileName that is written by mysqlbinlog, e.g. with
+// --read-from-remote-server --stop-never. The log is followed across rotation
+// and truncation according to the follow mode.
+func NewBinlog(logfileName string, follow string) (Binlog, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Binlog{}, err
+	}
+	return Binlog{LineReader: reader, current: &rowEvent{}}, nil
+}
+
+// Timestamp returns the time of a row returned by NextLine.
+func (m Binlog) Timestamp(s string) (time.Time, error) {
+	e, err := ParseRowEvent(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return e.Time, nil
+}
+
+// Precision returns the resolution of the event times of the binlog.
+func (m Binlog) Precision() time.Duration {
+	return time.Second
+}
+
+// NextLine returns the next changed row. Waits until a new row becomes
+// available or done is closed, static logs return io.EOF at their end instead.
+// Returns with an empty line and a nil error if the done channel was closed.
+func (m Binlog) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, m.current)
+}
+
+var (
+	// binlogEventRegex matches the header of binlog events, e.g.
+	// "#240408 12:50:59 server id 1  end_log_pos 1300 CRC32 0x1a2b3c4d	Write_rows: table id 90"
+	binlogEventRegex = regexp.MustCompile(`^#(\d{6}\s+\d{1,2}:\d{2}:\d{2})\s+server id`)
+
+	// binlogRowRegex matches the first line of a changed row.
+	binlogRowRegex = regexp.MustCompile("^### (INSERT INTO|UPDATE|DELETE FROM) (\\S+)$")
+
+	// binlogTypeRegex matches the column types printed by mysqlbinlog -vv.
+	binlogTypeRegex = regexp.MustCompile(`\s*/\*.*\*/$`)
+)
+
+// rowEvent assembles a changed row from the lines of a rows event:
+//
+//	#240408 12:50:59 server id 1  end_log_pos 1360 CRC32 0x1a2b3c4d 	Update_rows: table id 90 flags: STMT_END_F
+//	### UPDATE `jobs`.`job`
+//	### WHERE
+//	###   @1=2
+//	###   @2='World'
+//	### SET
+//	###   @1=2
+//	###   @2='Hello'
+//
+// Rows events may contain several rows, that get the time of their event.
+type rowEvent struct {
+	time      time.Time
+	operation string
+	table     string
+	lines     []string
+}
+
+// Add adds the physical line read from the log. Returns the previous row if
+// line starts a new row or ends the rows event.
+func (e *rowEvent) Add(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if m := binlogRowRegex.FindStringSubmatch(line); m != nil {
+		previous := e.flush()
+		e.operation = strings.Fields(m[1])[0]
+		e.table = strings.ReplaceAll(m[2], "`", "")
+		e.lines = []string{strings.ToLower(m[1]) + " " + e.table}
+		return previous, previous != ""
+	}
+	if strings.HasPrefix(line, "###") {
+		if e.operation != "" {
+			s := binlogTypeRegex.ReplaceAllString(strings.TrimSpace(strings.TrimPrefix(line, "###")), "")
+			if s == "WHERE" || s == "SET" {
+				s = strings.ToLower(s)
+			}
+			e.lines = append(e.lines, s)
+		}
+		return "", false
+	}
+	previous := e.flush()
+	if m := binlogEventRegex.FindStringSubmatch(line); m != nil {
+		if t, err := time.ParseInLocation("060102 15:04:05", strings.Join(strings.Fields(m[1]), " "), time.Local); err == nil {
+			e.time = t
+		}
+	}
+	return previous, previous != ""
+}
+
+// Flush returns the current row at the end of a static log.
+func (e *rowEvent) Flush(_ bool, end bool) (string, bool) {
+	// rows events are followed by the event that commits the
+	// transaction, which completes the last row
+	if !end {
+		return "", false
+	}
+	s := e.flush()
+	return s, s != ""
+}
+
+// flush returns the current row and resets it. The time is kept for the
+// succeeding rows of the same event. Returns an empty string if there is no
+// row.
+func (e *rowEvent) flush() string {
+	if e.operation == "" {
+		return ""
+	}
+	line := e.time.UTC().Format(time.RFC3339Nano) + "\t" + e.operation + " " + e.table + "\t" + strings.Join(e.lines, " ") + "\n"
+	e.operation = ""
+	e.table = ""
+	e.lines = nil
+	return line
+}
+
+// RowEvent is a single changed row returned by Binlog.NextLine.
+type RowEvent struct {
+	Time      time.Time
+	Operation string // INSERT, UPDATE or DELETE
+	Table     string // qualified table name, e.g. jobs.job
+	Statement string // statement-like text of the row
+}
+
+// ParseRowEvent parses a row returned by Binlog.NextLine.
+func ParseRowEvent(s string) (RowEvent, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return RowEvent{}, errors.New("string contains no row event")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return RowEvent{}, errors.New("string contains no valid Timestamp")
+	}
+	operation, table, ok := strings.Cut(fields[1], " ")
+	if !ok {
+		return RowEvent{}, errors.New("string contains no row event")
+	}
+	return RowEvent{Time: t, Operation: operation, Table: table, Statement: fields[2]}, nil
+}
@@ -0,0 +1,116 @@
+package mysql
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+const binlog = "# The proper term is pseudo_replica_mode, but we use this compatibility alias\n" +
+	"/*!50530 SET @@SESSION.PSEUDO_SLAVE_MODE=1*/;\n" +
+	"# at 4\n" +
+	"#240408 12:50:59 server id 1  end_log_pos 1300 CRC32 0x1a2b3c4d 	Table_map: `jobs`.`job` mapped to number 90\n" +
+	"# at 1300\n" +
+	"#240408 12:50:59 server id 1  end_log_pos 1360 CRC32 0x1a2b3c4d 	Write_rows: table id 90 flags: STMT_END_F\n" +
+	"### INSERT INTO `jobs`.`job`\n" +
+	"### SET\n" +
+	"###   @1=2 /* INT meta=0 nullable=0 is_null=0 */\n" +
+	"###   @2='Java Dev' /* VARSTRING(1020) meta=1020 nullable=1 is_null=0 */\n" +
+	"### INSERT INTO `jobs`.`job`\n" +
+	"### SET\n" +
+	"###   @1=3\n" +
+	"###   @2=NULL\n" +
+	"# at 1360\n" +
+	"#240408 12:50:59 server id 1  end_log_pos 1391 CRC32 0x1a2b3c4d 	Xid = 45\n" +
+	"COMMIT/*!*/;\n" +
+	"#240408  2:51:00 server id 1  end_log_pos 1500 CRC32 0x1a2b3c4d 	Update_rows: table id 90 flags: STMT_END_F\n" +
+	"### UPDATE `jobs`.`job`\n" +
+	"### WHERE\n" +
+	"###   @1=2\n" +
+	"###   @2='Java Dev'\n" +
+	"### SET\n" +
+	"###   @1=2\n" +
+	"###   @2='Go Dev'\n" +
+	"#240408  2:51:00 server id 1  end_log_pos 1600 CRC32 0x1a2b3c4d 	Delete_rows: table id 90 flags: STMT_END_F\n" +
+	"### DELETE FROM `jobs`.`job`\n" +
+	"### WHERE\n" +
+	"###   @1=3\n" +
+	"###   @2=NULL"
+
+func TestBinlogNextLine(t *testing.T) {
+	first := time.Date(2024, 4, 8, 12, 50, 59, 0, time.Local).UTC().Format(time.RFC3339Nano)
+	second := time.Date(2024, 4, 8, 2, 51, 0, 0, time.Local).UTC().Format(time.RFC3339Nano)
+	l := NewStaticBinlog(strings.NewReader(binlog))
+	assert.Equal(t, first+"\tINSERT jobs.job\tinsert into jobs.job set @1=2 @2='Java Dev'\n", nextLine(t, l))
+	assert.Equal(t, first+"\tINSERT jobs.job\tinsert into jobs.job set @1=3 @2=NULL\n", nextLine(t, l))
+	assert.Equal(t, second+"\tUPDATE jobs.job\tupdate jobs.job where @1=2 @2='Java Dev' set @1=2 @2='Go Dev'\n", nextLine(t, l))
+	assert.Equal(t, second+"\tDELETE jobs.job\tdelete from jobs.job where @1=3 @2=NULL\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBinlogWaitsForCommitOfLastRow(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "binlog.txt")
+	if err := os.WriteFile(name, []byte("#240408 12:50:59 server id 1  end_log_pos 1360 CRC32 0x1a2b3c4d 	Write_rows: table id 90 flags: STMT_END_F\n"+
+		"### INSERT INTO `jobs`.`job`\n"+
+		"### SET\n"+
+		"###   @1=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewBinlog(name, df.FollowPoll)
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// the row may continue until the next event
+	done := make(chan struct{})
+	time.AfterFunc(time.Second, func() { close(done) })
+	line, err := l.NextLine(done)
+	assert.Nil(t, err)
+	assert.Equal(t, "", line)
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteString("###   @2='Java Dev'\n" +
+		"#240408 12:50:59 server id 1  end_log_pos 1391 CRC32 0x1a2b3c4d 	Xid = 45\n")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+	line, err = l.NextLine(make(chan struct{}))
+	assert.Nil(t, err)
+	e, err := ParseRowEvent(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "insert into jobs.job set @1=2 @2='Java Dev'", e.Statement)
+}
+
+func TestBinlogMatchesRowOfStartSecond(t *testing.T) {
+	l := NewStaticBinlog(strings.NewReader(binlog))
+	ts, err := l.Timestamp(nextLine(t, l))
+	assert.NoError(t, err)
+	start := time.Date(2024, 4, 8, 12, 50, 59, 250000000, time.Local).UTC()
+	assert.True(t, df.InRecordingPeriod(df.FixedTimer{From: start}, l, ts))
+	assert.False(t, df.InRecordingPeriod(df.FixedTimer{From: start.Add(time.Second)}, l, ts))
+}
+
+func TestBinlogTokenize(t *testing.T) {
+	tokens := BinlogTokenizer{}.Tokenize("2024-04-08T10:50:59Z\tINSERT jobs.job\tinsert into jobs.job set @1=2 @2='Java Dev'\n", []string{"insert into jobs.job"})
+	assert.Equal(t, []string{"insert", "into", "jobs.job", "set", "@1=2", "@2=Java Dev"}, tokens)
+
+	e, err := ParseRowEvent("2024-04-08T10:50:59Z\tINSERT jobs.job\tinsert into jobs.job set @1=2\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT", e.Operation)
+	assert.Equal(t, "jobs.job", e.Table)
+	assert.Equal(t, time.Date(2024, 4, 8, 10, 50, 59, 0, time.UTC), e.Time)
+}
+
+func nextLine(t *testing.T, l Binlog) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
@@ -0,0 +1,18 @@
+package mysql
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// BinlogTokenizer tokenizes the rows returned by Binlog. The tokens contain the
+// operation, the table and the column values, e.g. ["insert", "into",
+// "jobs.job", "set", "@1=2", "@2=World"].
+type BinlogTokenizer struct {
+}
+
+func (m BinlogTokenizer) Tokenize(s string, patterns []string) []string {
+	if e, err := ParseRowEvent(s); err == nil {
+		s = e.Statement
+	}
+	return df.Tokenize(df.CutPrefix(s, patterns))
+}
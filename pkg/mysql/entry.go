@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headerRegex matches the first line of a general log entry:
+//
+//	2024-04-08T09:39:15.070009Z	 2549 Query	select * from jobs
+//
+// Submatches are the timestamp, the thread id, the command type and the
+// argument of the command.
+var headerRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}Z)\s+(\d+) ([A-Z][A-Za-z ]*?)(?:\t(.*))?$`)
+
+// Entry is a single entry of the general log. Argument holds the statement of
+// Query, Prepare and Execute commands.
+type Entry struct {
+	Time     time.Time
+	Thread   int
+	Command  string // Query, Prepare, Execute, Connect, Quit, Init DB, ...
+	Argument string
+}
+
+// ParseEntry parses the entry s as returned by Log.NextLine. Returns false if s
+// doesn't start with a general log header.
+func ParseEntry(s string) (Entry, bool) {
+	m := headerRegex.FindStringSubmatch(strings.TrimRight(s, "\r\n"))
+	if m == nil {
+		return Entry{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, m[1])
+	if err != nil {
+		return Entry{}, false
+	}
+	thread, err := strconv.Atoi(m[2])
+	if err != nil {
+		return Entry{}, false
+	}
+	return Entry{Time: t, Thread: thread, Command: m[3], Argument: m[4]}, true
+}
+
+// statement assembles an entry from its header line and the continuation lines
+// of statements that span several lines.
+type statement struct {
+	line string // header line joined with its continuation lines
+}
+
+// Add adds the physical line read from the log. Returns the previous entry if
+// line starts a new entry. Lines before the first header, e.g. the server's
+// startup banner, are dropped.
+func (s *statement) Add(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", false
+	}
+	if _, ok := ParseEntry(line); ok {
+		previous := s.flush()
+		s.line = line
+		return previous, previous != ""
+	}
+	if s.line != "" {
+		s.line = s.line + " " + strings.TrimLeft(line, "\t ")
+	}
+	return "", false
+}
+
+// Flush returns the current entry at the end of the lines written so far.
+func (s *statement) Flush(eof bool, _ bool) (string, bool) {
+	// entries are written at once, the last one is complete
+	if !eof {
+		return "", false
+	}
+	line := s.flush()
+	return line, line != ""
+}
+
+// flush returns the current entry and resets s. Returns an empty string if there
+// is no entry.
+func (s *statement) flush() string {
+	if s.line == "" {
+		return ""
+	}
+	line := s.line + "\n"
+	s.line = ""
+	return line
+}
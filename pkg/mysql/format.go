@@ -0,0 +1,23 @@
+package mysql
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name:       "mysql",
+		LogFactory: func(channel df.Channel) df.LogFactory { return LogFactory{Follow: channel.Follow} },
+		Tokenizer:  Tokenizer{},
+	})
+	df.RegisterFormat(df.Format{
+		Name:       "mysql-slow",
+		LogFactory: func(channel df.Channel) df.LogFactory { return SlowLogFactory{Follow: channel.Follow} },
+		Tokenizer:  SlowTokenizer{},
+	})
+	df.RegisterFormat(df.Format{
+		Name:       "mysql-binlog",
+		LogFactory: func(channel df.Channel) df.LogFactory { return BinlogFactory{Follow: channel.Follow} },
+		Tokenizer:  BinlogTokenizer{},
+	})
+}
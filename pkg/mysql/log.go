@@ -1,51 +1,32 @@
 package mysql
 
 import (
-	"bufio"
 	"errors"
 	"io"
-	"os"
 	"time"
 
 	"github.com/rwirdemann/datafrog/pkg/df"
-	log "github.com/sirupsen/logrus"
 )
 
 type Log struct {
-	logfile *os.File
-	reader  *bufio.Reader
+	df.LineReader
+	current *statement // entry assembled from the lines read so far
 }
 
-func NewMYSQLLog(logfileName string) (Log, error) {
-	logfile, err := os.Open(logfileName)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return Log{logfile: logfile, reader: bufio.NewReader(logfile)}, err
-}
-
-// Tail sets the read cursor of the log file to its end.
-func (m Log) Tail() error {
-	log.Printf("tailing %s...", m.logfile.Name())
-	defer log.Printf("tailing successful!")
-	for {
-		_, err := m.reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			} else {
-				return err
-			}
-		}
-	}
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r), current: &statement{}}
 }
 
-func (m Log) Close() {
-	err := m.logfile.Close()
+// NewMYSQLLog opens the general query log logfileName. The log is followed
+// across rotation and truncation according to the follow mode.
+func NewMYSQLLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
 	if err != nil {
-		log.Fatal(err)
+		return Log{}, err
 	}
-	log.Printf("%s closed", m.logfile.Name())
+	return Log{LineReader: reader, current: &statement{}}, nil
 }
 
 func (m Log) Timestamp(s string) (time.Time, error) {
@@ -56,25 +37,11 @@ func (m Log) Timestamp(s string) (time.Time, error) {
 	return t, nil
 }
 
-// NextLine reads the next line terminated by the delimiter \n from the log
-// file. Waits until a new line becomes available. Returns with an empty line
-// and a nil error if the done channel was closed.
+// NextLine returns the next entry of the log file including thread id and
+// command type. Lines without header continue the statement of the previous
+// entry and are joined with it. Waits until a new entry becomes available or
+// done is closed, static logs return io.EOF at their end instead. Returns with
+// an empty line and a nil error if the done channel was closed.
 func (m Log) NextLine(done chan struct{}) (string, error) {
-	for {
-		select {
-		default:
-			line, err := m.reader.ReadString('\n')
-			if err != nil {
-				if err == io.EOF {
-					time.Sleep(500 * time.Millisecond)
-					continue
-				}
-				return "", err
-			}
-			return line, nil
-		case <-done:
-			log.Printf("nextline: done channel closed")
-			return "", nil
-		}
-	}
+	return m.LineReader.NextLine(done, m.current)
 }
@@ -1,11 +1,48 @@
 package mysql
 
-import "github.com/rwirdemann/datafrog/pkg/df"
+import (
+	"io"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
 
 type LogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
 }
 
 func (f LogFactory) Create(filename string) (df.Log, error) {
-	log, err := NewMYSQLLog(filename)
+	log, err := NewMYSQLLog(filename, f.Follow)
 	return log, err
 }
+
+func (f LogFactory) Static(r io.Reader) df.Log {
+	return NewStaticLog(r)
+}
+
+// SlowLogFactory creates logs of the mysql-slow format.
+type SlowLogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f SlowLogFactory) Create(filename string) (df.Log, error) {
+	log, err := NewSlowLog(filename, f.Follow)
+	return log, err
+}
+
+func (f SlowLogFactory) Static(r io.Reader) df.Log {
+	return NewStaticSlowLog(r)
+}
+
+// BinlogFactory creates logs of the mysql-binlog format.
+type BinlogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f BinlogFactory) Create(filename string) (df.Log, error) {
+	log, err := NewBinlog(filename, f.Follow)
+	return log, err
+}
+
+func (f BinlogFactory) Static(r io.Reader) df.Log {
+	return NewStaticBinlog(r)
+}
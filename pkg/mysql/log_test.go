@@ -1,6 +1,8 @@
 package mysql
 
 import (
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,3 +16,39 @@ func TestTimestamp(t *testing.T) {
 	expected, err := time.Parse(time.RFC3339Nano, "2024-04-02T06:38:05.015501Z")
 	assert.Equal(t, expected, actual)
 }
+
+func TestMultiLineStatement(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("/usr/sbin/mysqld, Version: 8.3.0 (MySQL Community Server - GPL). started with:\n" +
+		"Time                 Id Command    Argument\n" +
+		"2024-04-08T09:39:15.070009Z	 2549 Query	select *\n" +
+		"  from job\n" +
+		"  where id=5\n" +
+		"2024-04-08T09:39:15.080009Z	 2549 Quit	\n"))
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.070009Z	 2549 Query	select * from job where id=5\n", line)
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.080009Z	 2549 Quit	\n", line)
+	_, err = l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestParseEntry(t *testing.T) {
+	e, ok := ParseEntry("2024-04-08T09:39:15.070009Z	 2549 Execute	select * from job where id=5\n")
+	assert.True(t, ok)
+	assert.Equal(t, 2549, e.Thread)
+	assert.Equal(t, "Execute", e.Command)
+	assert.Equal(t, "select * from job where id=5", e.Argument)
+
+	e, ok = ParseEntry("2024-04-08T09:39:15.060009Z	 2549 Connect	root@localhost on jobs using TCP/IP")
+	assert.True(t, ok)
+	assert.Equal(t, "Connect", e.Command)
+
+	e, ok = ParseEntry("2024-04-08T09:39:15.090009Z	 2549 Quit")
+	assert.True(t, ok)
+	assert.Equal(t, "Quit", e.Command)
+
+	_, ok = ParseEntry("  from job")
+	assert.False(t, ok)
+}
@@ -0,0 +1,174 @@
+package mysql
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// SlowLog reads the slow query log of MySQL. Enable it for all statements by:
+//
+//	slow_query_log = 1
+//	long_query_time = 0
+//
+// Each block of the slow log is returned as single line that contains the
+// timestamp, the thread id, the block's metadata and the statement:
+//
+//	2024-04-08T09:39:15.070009Z	2549 Query_time: 0.000123 Rows_sent: 1 Rows_examined: 1	select * from job where id=5
+type SlowLog struct {
+	df.LineReader
+	current *slowBlock // block assembled from the lines read so far
+}
+
+// NewStaticSlowLog creates a log that reads the finished slow log r.
+func NewStaticSlowLog(r io.Reader) SlowLog {
+	return SlowLog{LineReader: df.NewStaticLineReader(r), current: &slowBlock{}}
+}
+
+// NewSlowLog opens the slow query log logfileName. The log is followed across
+// rotation and truncation according to the follow mode.
+func NewSlowLog(logfileName string, follow string) (SlowLog, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return SlowLog{}, err
+	}
+	return SlowLog{LineReader: reader, current: &slowBlock{}}, nil
+}
+
+func (m SlowLog) Timestamp(s string) (time.Time, error) {
+	return Log{}.Timestamp(s)
+}
+
+// NextLine returns the next block of the slow log as single line. Waits until a
+// new block becomes available or done is closed, static logs return io.EOF at
+// their end instead. Returns with an empty line and a nil error if the done
+// channel was closed.
+func (m SlowLog) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, m.current)
+}
+
+// slowTimeout is the time to wait for further lines of the last block when the
+// end of the log was reached. The server may write a block in several chunks.
+const slowTimeout = time.Second
+
+var (
+	slowTimeRegex      = regexp.MustCompile(`^# Time: (\S+)`)
+	slowThreadRegex    = regexp.MustCompile(`^# User@Host: .*Id:\s*(\d+)`)
+	slowMetadataRegex  = regexp.MustCompile(`(Query_time|Rows_sent|Rows_examined): (\S+)`)
+	slowTimestampRegex = regexp.MustCompile(`^SET timestamp=(\d+);$`)
+	slowUseRegex       = regexp.MustCompile(`^use \S+;$`)
+)
+
+// slowBlock assembles a statement from the lines of a slow log block:
+//
+//	# Time: 2024-04-08T09:39:15.070009Z
+//	# User@Host: root[root] @ localhost []  Id:  2549
+//	# Query_time: 0.000123  Lock_time: 0.000001 Rows_sent: 1  Rows_examined: 1
+//	SET timestamp=1712569155;
+//	select * from job
+//	where id=5;
+//
+// The time of blocks without "# Time:" line is taken from the SET timestamp
+// statement, only blocks without both carry the time of the previous block.
+type slowBlock struct {
+	time      string
+	timed     bool // time was read from the "# Time:" line of the block
+	thread    string
+	metadata  []string
+	statement []string
+	read      time.Time // time the last line was read
+}
+
+// Add adds the physical line read from the log. Returns the previous block if
+// line starts a new block.
+func (b *slowBlock) Add(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" || isBanner(line) {
+		return "", false
+	}
+	b.read = time.Now()
+	var previous string
+	if strings.HasPrefix(line, "# ") && len(b.statement) > 0 {
+		previous = b.flush()
+	}
+	switch {
+	case slowTimeRegex.MatchString(line):
+		b.time = slowTimeRegex.FindStringSubmatch(line)[1]
+		b.timed = true
+	case slowThreadRegex.MatchString(line):
+		b.thread = slowThreadRegex.FindStringSubmatch(line)[1]
+	case strings.HasPrefix(line, "# Query_time:"):
+		b.metadata = nil
+		for _, m := range slowMetadataRegex.FindAllStringSubmatch(line, -1) {
+			b.metadata = append(b.metadata, m[1]+": "+m[2])
+		}
+	case strings.HasPrefix(line, "# "):
+	case slowTimestampRegex.MatchString(line) && len(b.statement) == 0:
+		if !b.timed {
+			seconds, _ := strconv.ParseInt(slowTimestampRegex.FindStringSubmatch(line)[1], 10, 64)
+			b.time = time.Unix(seconds, 0).UTC().Format("2006-01-02T15:04:05.000000Z")
+		}
+	case slowUseRegex.MatchString(line) && len(b.statement) == 0:
+	default:
+		b.statement = append(b.statement, strings.TrimSpace(line))
+	}
+	return previous, previous != ""
+}
+
+// Flush returns the current block at the end of a static log or if no further
+// lines were written for slowTimeout. Otherwise the block is completed by the
+// header of the next block.
+func (b *slowBlock) Flush(eof bool, end bool) (string, bool) {
+	if !end && !(eof && time.Since(b.read) > slowTimeout) {
+		return "", false
+	}
+	s := b.flush()
+	return s, s != ""
+}
+
+// flush returns the current block and resets it. The time is kept for blocks
+// without time. Returns an empty string if there is no complete block.
+func (b *slowBlock) flush() string {
+	if len(b.statement) == 0 || b.time == "" {
+		b.statement = nil
+		return ""
+	}
+	thread := b.thread
+	if thread == "" {
+		thread = "0"
+	}
+	statement := strings.TrimSuffix(strings.Join(b.statement, " "), ";")
+	line := b.time + "\t" + thread + " " + strings.Join(b.metadata, " ") + "\t" + statement + "\n"
+	b.timed = false
+	b.thread = ""
+	b.metadata = nil
+	b.statement = nil
+	return line
+}
+
+// isBanner returns true if line is part of the banner the server writes when
+// it opens the log file.
+func isBanner(line string) bool {
+	return strings.HasPrefix(line, "Tcp port:") ||
+		(strings.HasPrefix(line, "Time ") && strings.Contains(line, "Id Command")) ||
+		(strings.Contains(line, ", Version: ") && strings.HasSuffix(line, "started with:"))
+}
+
+// ParseSlowEntry splits a line returned by SlowLog.NextLine into its metadata
+// and its statement.
+func ParseSlowEntry(s string) (map[string]string, string, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return nil, "", errors.New("string contains no slow log entry")
+	}
+	metadata := make(map[string]string)
+	for _, m := range slowMetadataRegex.FindAllStringSubmatch(fields[1], -1) {
+		metadata[m[1]] = m[2]
+	}
+	return metadata, fields[2], nil
+}
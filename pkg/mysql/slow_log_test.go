@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+const slowLog = `/usr/sbin/mysqld, Version: 8.3.0 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-04-08T09:39:15.070009Z
+# User@Host: root[root] @ localhost []  Id:  2549
+# Query_time: 0.000123  Lock_time: 0.000001 Rows_sent: 1  Rows_examined: 7
+use jobs;
+SET timestamp=1712569155;
+select * from job
+  where id=5;
+# User@Host: root[root] @ localhost []  Id:  2550
+# Query_time: 0.002000  Lock_time: 0.000001 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1712569156;
+insert into job (title) values ('Hello');
+`
+
+func TestSlowLogNextLine(t *testing.T) {
+	l := NewStaticSlowLog(strings.NewReader(slowLog))
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.070009Z\t2549 Query_time: 0.000123 Rows_sent: 1 Rows_examined: 7\tselect * from job where id=5\n", line)
+
+	// blocks without "# Time:" line take the time of the SET timestamp statement
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:16.000000Z\t2550 Query_time: 0.002000 Rows_sent: 0 Rows_examined: 0\tinsert into job (title) values ('Hello')\n", line)
+
+	_, err = l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSlowLogTimeFromSetTimestamp(t *testing.T) {
+	l := NewStaticSlowLog(strings.NewReader("# Query_time: 0.000123  Lock_time: 0.000001 Rows_sent: 1  Rows_examined: 7\nSET timestamp=1712569155;\nselect 1;\n"))
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.000000Z\t0 Query_time: 0.000123 Rows_sent: 1 Rows_examined: 7\tselect 1\n", line)
+	_, err = l.Timestamp(line)
+	assert.Nil(t, err)
+}
+
+func TestSlowLogCarriesTimeOfPreviousBlock(t *testing.T) {
+	l := NewStaticSlowLog(strings.NewReader("# Time: 2024-04-08T09:39:15.070009Z\nSET timestamp=1712569155;\nselect 1;\n" +
+		"# Query_time: 0.000123  Lock_time: 0.000001 Rows_sent: 1  Rows_examined: 7\nselect 2;\n"))
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.070009Z\t0 \tselect 1\n", line)
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15.070009Z\t0 Query_time: 0.000123 Rows_sent: 1 Rows_examined: 7\tselect 2\n", line)
+}
+
+func TestSlowLogWaitsForSplitBlock(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "slow.log")
+	if err := os.WriteFile(name, []byte("# Time: 2024-04-08T09:39:15.070009Z\n"+
+		"# User@Host: root[root] @ localhost []  Id:  2549\n"+
+		"select * from job\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewSlowLog(name, df.FollowPoll)
+	assert.Nil(t, err)
+	defer l.Close()
+
+	lines := make(chan string)
+	go func() {
+		line, _ := l.NextLine(make(chan struct{}))
+		lines <- line
+	}()
+	time.Sleep(slowTimeout / 2)
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteString("  where id=5;\n")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	assert.Equal(t, "2024-04-08T09:39:15.070009Z\t2549 \tselect * from job where id=5\n", <-lines)
+}
+
+func TestSlowTokenizer(t *testing.T) {
+	s := "2024-04-08T09:39:15.070009Z\t2549 Query_time: 0.000123 Rows_sent: 1 Rows_examined: 7\tselect * from job where id=5\n"
+	assert.Equal(t, []string{"select", "*", "from", "job", "where", "id=5"}, SlowTokenizer{}.Tokenize(s, []string{"select"}))
+	assert.Equal(t, map[string]string{"Query_time": "0.000123", "Rows_sent": "1", "Rows_examined": "7"}, SlowTokenizer{}.Metadata(s))
+}
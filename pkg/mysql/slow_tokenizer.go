@@ -0,0 +1,25 @@
+package mysql
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// SlowTokenizer tokenizes the statements returned by SlowLog.
+type SlowTokenizer struct {
+}
+
+func (m SlowTokenizer) Tokenize(s string, patterns []string) []string {
+	if _, statement, err := ParseSlowEntry(s); err == nil {
+		s = statement
+	}
+	return df.Tokenize(df.CutPrefix(s, patterns))
+}
+
+// Metadata returns Query_time, Rows_sent and Rows_examined of s.
+func (m SlowTokenizer) Metadata(s string) map[string]string {
+	metadata, _, err := ParseSlowEntry(s)
+	if err != nil || len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
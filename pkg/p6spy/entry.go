@@ -0,0 +1,211 @@
+package p6spy
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headerRegex matches the beginning of an entry written by p6spy's
+// SingleLineFormat, optionally prefixed by the pattern of the application's
+// logger:
+//
+//	1712569155070|2|statement|connection 7|url jdbc:postgresql://localhost/jobs|select * from job where id=?|select * from job where id=5
+//
+// Submatches are the time, the elapsed milliseconds, the category and the
+// connection id. The url is optional. The rest of the entry contains the
+// prepared statement and the statement with inlined values.
+var headerRegex = regexp.MustCompile(`(\d{13}|\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3})\|(\d+)\|(\w+)\|(?:connection ?)?(\d+)\|(?:url [^|]*\|)?`)
+
+// dateLayout is the layout of the p6spy setting dateformat=yyyy-MM-dd HH:mm:ss.SSS.
+const dateLayout = "2006-01-02 15:04:05.000"
+
+// entry assembles a p6spy entry from its first line and the continuation lines
+// of statements that span several lines. p6spy writes the prepared statement
+// and the statement with inlined values with the same number of lines, thus an
+// entry of n statement lines is complete after 2n-1 lines, whose middle line
+// separates both statements by "|".
+type entry struct {
+	header []string       // submatches of headerRegex
+	lines  []string       // prepared statement and statement
+	logger *regexp.Regexp // matches lines of the application's logger, may be nil
+}
+
+// Add adds the physical line read from the log. Returns the entry once it is
+// complete. Lines that neither belong to an entry nor start a new one are
+// dropped, e.g. other messages of the application that are logged while p6spy
+// writes a statement.
+func (e *entry) Add(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	loc := headerRegex.FindStringSubmatchIndex(line)
+	if loc == nil {
+		if e.header == nil || (e.logger != nil && e.logger.MatchString(line)) {
+			return "", false
+		}
+		e.lines = append(e.lines, line)
+		return e.next()
+	}
+
+	// entries that never got complete are returned with the next entry
+	previous := e.flush()
+	for i := 2; i < 10; i += 2 {
+		e.header = append(e.header, line[loc[i]:loc[i+1]])
+	}
+	e.lines = []string{line[loc[1]:]}
+	e.logger = loggerRegex(line[:loc[0]])
+	if previous != "" {
+		return previous, true
+	}
+	return e.next()
+}
+
+// next returns the entry if it is complete.
+func (e *entry) next() (string, bool) {
+	if _, _, ok := split(e.lines); !ok {
+		return "", false
+	}
+	s := e.flush()
+	return s, s != ""
+}
+
+// split returns the prepared statement and the statement with inlined values
+// of an entry of 2n-1 lines. Both are separated by the "|" of the middle line
+// at which the statement is an instance of the prepared statement, thus "|" in
+// operators like || or in values don't split the entry.
+func split(lines []string) (string, string, bool) {
+	if len(lines)%2 == 0 {
+		return "", "", false
+	}
+	n := len(lines) / 2
+	middle := lines[n]
+	for i := 0; i < len(middle); i++ {
+		if middle[i] != '|' {
+			continue
+		}
+		prepared := join(append(lines[:n:n], middle[:i]))
+		statement := join(append([]string{middle[i+1:]}, lines[n+1:]...))
+		if instanceRegex(prepared).MatchString(statement) {
+			return prepared, statement, true
+		}
+	}
+	return "", "", false
+}
+
+// instanceRegex returns a regex that matches the statements of the prepared
+// statement with values inlined for its placeholders. Question marks in string
+// literals are no placeholders.
+func instanceRegex(prepared string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	quoted := false
+	for _, r := range prepared {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			b.WriteString("'")
+		case r == '?' && !quoted:
+			b.WriteString(".*")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// loggerRegex returns a regex that matches lines that begin like prefix, the
+// pattern of the application's logger in front of a p6spy entry, e.g. lines
+// that start with a date. Returns nil if p6spy writes its own log.
+func loggerRegex(prefix string) *regexp.Regexp {
+	word, _, _ := strings.Cut(strings.TrimSpace(prefix), " ")
+	if word == "" {
+		return nil
+	}
+	return regexp.MustCompile("^" + digitsRegex.ReplaceAllString(regexp.QuoteMeta(word), `\d+`))
+}
+
+var digitsRegex = regexp.MustCompile(`\d+`)
+
+// statement returns the statement with inlined values joined into a single
+// line. Entries that aren't complete are returned as is.
+func (e *entry) statement() string {
+	if _, statement, ok := split(e.lines); ok {
+		return statement
+	}
+	return join(e.lines)
+}
+
+func join(lines []string) string {
+	var parts []string
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			parts = append(parts, l)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Flush returns the entry if it is complete. Entries that didn't get complete
+// are returned at the end of a static log.
+func (e *entry) Flush(_ bool, end bool) (string, bool) {
+	if !end {
+		return e.next()
+	}
+	s := e.flush()
+	return s, s != ""
+}
+
+// flush returns the current entry and resets it. Returns an empty string if
+// there is no entry or if its time is invalid.
+func (e *entry) flush() string {
+	if e.header == nil {
+		return ""
+	}
+	defer func() { e.header, e.lines, e.logger = nil, nil, nil }()
+	t, err := parseTime(e.header[0])
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano) + "\t" + e.header[3] + " " + e.header[1] + " " + e.header[2] + "\t" + e.statement() + "\n"
+}
+
+// parseTime parses epoch milliseconds, p6spy's default, or times formatted by
+// dateLayout in local time.
+func parseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.ParseInLocation(dateLayout, s, time.Local)
+}
+
+// Entry is a single entry returned by Log.NextLine.
+type Entry struct {
+	Time       time.Time
+	Connection string
+	Elapsed    time.Duration
+	Category   string // statement, batch, commit, rollback, ...
+	Statement  string // statement with inlined values
+}
+
+// ParseEntry parses the entry s as returned by Log.NextLine.
+func ParseEntry(s string) (Entry, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return Entry{}, errors.New("string contains no p6spy entry")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Entry{}, errors.New("string contains no valid Timestamp")
+	}
+	meta := strings.Fields(fields[1])
+	if len(meta) != 3 {
+		return Entry{}, errors.New("string contains no p6spy entry")
+	}
+	elapsed, err := strconv.Atoi(meta[1])
+	if err != nil {
+		return Entry{}, errors.New("string contains no p6spy entry")
+	}
+	return Entry{Time: t, Connection: meta[0], Elapsed: time.Duration(elapsed) * time.Millisecond, Category: meta[2], Statement: fields[2]}, nil
+}
@@ -0,0 +1,13 @@
+package p6spy
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name:       "p6spy",
+		LogFactory: func(channel df.Channel) df.LogFactory { return LogFactory{Follow: channel.Follow} },
+		Tokenizer:  Tokenizer{},
+	})
+}
@@ -0,0 +1,54 @@
+// Package p6spy implements the p6spy format, which reads SQL statements logged
+// by p6spy within the log of a Java application. It allows to record and verify
+// applications without access to the log of their database server.
+package p6spy
+
+import (
+	"io"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Log reads p6spy entries from an application log. Entries are returned as
+// single line that contains the time in UTC, the connection id, the elapsed
+// milliseconds, the category and the statement with inlined values:
+//
+//	2024-04-08T09:39:15.07Z	7 2 statement	select * from job where id=5
+type Log struct {
+	df.LineReader
+	current *entry // entry assembled from the lines read so far
+}
+
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r), current: &entry{}}
+}
+
+// NewP6spyLog opens the log logfileName. The log is followed across rotation
+// and truncation according to the follow mode.
+func NewP6spyLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader, current: &entry{}}, nil
+}
+
+// Timestamp returns the time of an entry returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	e, err := ParseEntry(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return e.Time, nil
+}
+
+// NextLine returns the next p6spy entry joined with its continuation lines.
+// Waits until a new entry becomes available or done is closed, static logs
+// return io.EOF at their end instead. Returns with an empty line and a nil
+// error if the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, m.current)
+}
@@ -0,0 +1,20 @@
+package p6spy
+
+import (
+	"io"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type LogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f LogFactory) Create(filename string) (df.Log, error) {
+	log, err := NewP6spyLog(filename, f.Follow)
+	return log, err
+}
+
+func (f LogFactory) Static(r io.Reader) df.Log {
+	return NewStaticLog(r)
+}
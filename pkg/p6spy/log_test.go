@@ -0,0 +1,107 @@
+package p6spy
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextLine(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("2024-04-08 11:39:15.070  INFO 4711 --- [main] p6spy : 1712569155070|2|statement|connection 7|url jdbc:postgresql://localhost/jobs|select * from job where id=?|select * from job where id=5\n" +
+		"2024-04-08 11:39:15.071  INFO 4711 --- [main] o.s.web.servlet.DispatcherServlet : Completed initialization\n" +
+		"1712569155080|13|statement|8|insert into job (description, id)\n" +
+		"  values (?, ?)|insert into job (description, id)\n" +
+		"  values ('World', 6)\n" +
+		"2024-04-08 11:39:15.090|0|commit|connection 8|url jdbc:postgresql://localhost/jobs||"))
+	assert.Equal(t, "2024-04-08T09:39:15.07Z\t7 2 statement\tselect * from job where id=5\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-08T09:39:15.08Z\t8 13 statement\tinsert into job (description, id) values ('World', 6)\n", nextLine(t, l))
+	expected := time.Date(2024, 4, 8, 11, 39, 15, 90000000, time.Local).UTC().Format(time.RFC3339Nano)
+	assert.Equal(t, expected+"\t8 0 commit\t\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNextLineSplitsAtStatement(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("1712569155070|2|statement|7|select title || ' (' || id || ')' from job where id=?|select title || ' (' || id || ')' from job where id=5\n" +
+		"1712569155080|1|statement|7|update job set title=? where id=?|update job set title='Java|Go' where id=5\n" +
+		"1712569155090|1|statement|7|select * from job where title='a|b'|select * from job where title='a|b'\n"))
+	assert.Equal(t, "2024-04-08T09:39:15.07Z\t7 2 statement\tselect title || ' (' || id || ')' from job where id=5\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-08T09:39:15.08Z\t7 1 statement\tupdate job set title='Java|Go' where id=5\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-08T09:39:15.09Z\t7 1 statement\tselect * from job where title='a|b'\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNextLineSkipsInterleavedLines(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("2024-04-08 11:39:15.080  INFO 4711 --- [main] p6spy : 1712569155080|13|statement|8|insert into job (description, id)\n" +
+		"2024-04-08 11:39:15.081  INFO 4711 --- [pool-1] o.s.web.servlet.DispatcherServlet : Completed initialization\n" +
+		"  values (?, ?)|insert into job (description, id)\n" +
+		"  values ('World', 6)\n"))
+	assert.Equal(t, "2024-04-08T09:39:15.08Z\t8 13 statement\tinsert into job (description, id) values ('World', 6)\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNextLineReturnsEntryOnceComplete(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "spy.log")
+	if err := os.WriteFile(name, []byte("1712569155080|13|statement|8|insert into job (description, id)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewP6spyLog(name, df.FollowPoll)
+	assert.Nil(t, err)
+	defer l.Close()
+
+	appendTo(t, name, "  values (?, ?)|insert into job (description, id)\n")
+	lines := make(chan string)
+	go func() {
+		line, _ := l.NextLine(make(chan struct{}))
+		lines <- line
+	}()
+	appendTo(t, name, "  values ('World', 6)\n")
+	assert.Equal(t, "2024-04-08T09:39:15.08Z\t8 13 statement\tinsert into job (description, id) values ('World', 6)\n", <-lines)
+}
+
+func TestParseEntry(t *testing.T) {
+	e, err := ParseEntry("2024-04-08T09:39:15.07Z\t7 2 statement\tselect * from job where id=5\n")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 8, 9, 39, 15, 70000000, time.UTC), e.Time)
+	assert.Equal(t, "7", e.Connection)
+	assert.Equal(t, 2*time.Millisecond, e.Elapsed)
+	assert.Equal(t, "statement", e.Category)
+	assert.Equal(t, "select * from job where id=5", e.Statement)
+
+	_, err = ParseEntry("select * from job")
+	assert.Error(t, err)
+}
+
+func TestTokenize(t *testing.T) {
+	s := "2024-04-08T09:39:15.07Z\t7 2 statement\tselect * from job where title='Java Dev'\n"
+	tokens := Tokenizer{}.Tokenize(s, []string{"select"})
+	assert.Equal(t, []string{"select", "*", "from", "job", "where", "title=Java Dev"}, tokens)
+	assert.Equal(t, map[string]string{"connection": "7", "elapsed_ms": "2"}, Tokenizer{}.Metadata(s))
+}
+
+func nextLine(t *testing.T, l Log) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
+
+func appendTo(t *testing.T, name string, s string) {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,28 @@
+package p6spy
+
+import (
+	"strconv"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type Tokenizer struct {
+}
+
+// Tokenize cuts time, connection id, elapsed time and category from s. The
+// statement is split by spaces into single tokens afterward.
+func (t Tokenizer) Tokenize(s string, patterns []string) []string {
+	if e, err := ParseEntry(s); err == nil {
+		s = e.Statement
+	}
+	return df.Tokenize(df.CutPrefix(s, patterns))
+}
+
+// Metadata returns the connection id and the elapsed milliseconds of s.
+func (t Tokenizer) Metadata(s string) map[string]string {
+	e, err := ParseEntry(s)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{"connection": e.Connection, "elapsed_ms": strconv.FormatInt(e.Elapsed.Milliseconds(), 10)}
+}
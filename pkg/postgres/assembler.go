@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// detailTimeout is the time to wait for the DETAIL line with the bind
+// parameters of a statement or for further continuation lines of the last
+// message when the end of the log was reached.
+const detailTimeout = time.Second
+
+// headerRegex matches the first line of a log message, given the default
+// log_line_prefix '%m [%p] '. Further prefix fields between pid and severity
+// are allowed. Submatches are the pid, the severity and the message.
+var headerRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?\s.*?\[(\d+)\].*?([A-Z]+):  (.*)$`)
+
+// entry is a log message of a single backend that is assembled from several
+// physical lines.
+type entry struct {
+	pid    string
+	line   string    // header line joined with its continuation lines
+	detail bool      // true if the statement waits for its bind parameters
+	read   time.Time // time the last line of the message was read
+}
+
+// assembler assembles complete statements from the physical lines of a
+// PostgreSQL stderr log. Lines of concurrent backends may be interleaved, thus
+// statements are matched with the DETAIL lines of their bind parameters by the
+// backend's pid. Lines without header continue the previous message.
+// Statements are emitted in the order of their header lines. At the end of the
+// log statements that wait for their parameters don't hold back the complete
+// statements of other backends.
+type assembler struct {
+	entries []*entry
+	last    *entry // receives continuation lines
+}
+
+// add adds the physical line read from the log.
+func (a *assembler) add(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return
+	}
+	m := headerRegex.FindStringSubmatch(line)
+	if m == nil {
+		if a.last != nil {
+			a.last.line = a.last.line + " " + strings.TrimLeft(line, "\t ")
+			a.last.read = time.Now()
+			if !a.last.detail && placeholderRegex.MatchString(line) {
+				a.last.detail = true
+			}
+		}
+		return
+	}
+
+	pid, severity, message := m[1], m[2], m[3]
+	if e := a.waiting(pid); e != nil {
+		// the next message of a backend ends the wait for parameters
+		e.detail = false
+		if severity == "DETAIL" {
+			e.line = bind(e.line, message)
+			a.last = nil
+			return
+		}
+	}
+	e := &entry{pid: pid, line: line, detail: placeholderRegex.MatchString(message), read: time.Now()}
+	a.entries = append(a.entries, e)
+	a.last = e
+}
+
+// waiting returns the statement of pid that waits for its bind parameters or
+// nil.
+func (a *assembler) waiting(pid string) *entry {
+	for i := len(a.entries) - 1; i >= 0; i-- {
+		if a.entries[i].pid == pid && a.entries[i].detail {
+			return a.entries[i]
+		}
+	}
+	return nil
+}
+
+// Add adds the physical line read from the log. Returns the next complete
+// statement.
+func (a *assembler) Add(line string) (string, bool) {
+	a.add(line)
+	return a.next(false, false)
+}
+
+// Flush returns the next complete statement, see next.
+func (a *assembler) Flush(eof bool, end bool) (string, bool) {
+	return a.next(eof, end)
+}
+
+// next returns the next complete statement. Statements that wait for their
+// parameters and the last message, which may get further continuation lines,
+// are complete at the end of the log if no further lines were read for
+// detailTimeout. Until then, they only hold back the statements of other
+// backends while lines are read. If final is true all statements are emitted.
+func (a *assembler) next(eof bool, final bool) (string, bool) {
+	for i, e := range a.entries {
+		if !final && (e.detail || e == a.last) && !(eof && time.Since(e.read) > detailTimeout) {
+			if eof {
+				continue
+			}
+			return "", false
+		}
+		a.entries = append(a.entries[:i], a.entries[i+1:]...)
+		if e == a.last {
+			a.last = nil
+		}
+		return e.line + "\n", true
+	}
+	return "", false
+}
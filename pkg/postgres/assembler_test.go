@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterleavedDetailLines(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(
+		"2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: insert into job (title, id) values ($1, $2)\n" +
+			"2024-04-19 10:12:16.890 CEST [2] LOG:  execute <unnamed>: select * from job where id=$1\n" +
+			"2024-04-19 10:12:16.891 CEST [2] DETAIL:  parameters: $1 = '7'\n" +
+			"2024-04-19 10:12:16.892 CEST [1] DETAIL:  parameters: $1 = 'Hello', $2 = '1'\n"))
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: insert into job (title, id) values ('Hello', '1')\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-19 10:12:16.890 CEST [2] LOG:  execute <unnamed>: select * from job where id='7'\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBindsMoreThanNineParameters(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(
+		"2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: select $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11\n" +
+			"2024-04-19 10:12:16.889 CEST [1] DETAIL:  parameters: $1 = '1', $2 = '2', $3 = '3', $4 = '4', $5 = '5', $6 = '6', $7 = '7', $8 = '8', $9 = '9', $10 = 'ten', $11 = NULL\n"))
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: select '1', '2', '3', '4', '5', '6', '7', '8', '9', 'ten', NULL\n", nextLine(t, l))
+}
+
+func TestJoinsContinuationLines(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(
+		"2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: select *\n" +
+			"\tfrom job\n" +
+			"\twhere id=$1\n" +
+			"2024-04-19 10:12:16.890 CEST [2] LOG:  statement: select 1\n" +
+			"2024-04-19 10:12:16.891 CEST [1] DETAIL:  parameters: $1 = '7'\n"))
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: select * from job where id='7'\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-19 10:12:16.890 CEST [2] LOG:  statement: select 1\n", nextLine(t, l))
+}
+
+func TestStatementWithoutDetailLine(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(
+		"2024-04-19 10:12:16.889 CEST [1] LOG:  statement: select * from job where id=$1\n" +
+			"2024-04-19 10:12:16.890 CEST [1] LOG:  statement: select 1\n"))
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [1] LOG:  statement: select * from job where id=$1\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-19 10:12:16.890 CEST [1] LOG:  statement: select 1\n", nextLine(t, l))
+}
+
+func TestWaitingStatementDoesNotHoldBackOtherBackends(t *testing.T) {
+	a := &assembler{}
+	a.Add("2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: select * from job where id=$1\n")
+	a.Add("2024-04-19 10:12:16.890 CEST [2] LOG:  statement: select 1\n")
+	a.Add("2024-04-19 10:12:16.891 CEST [3] LOG:  statement: select 2\n")
+	_, ok := a.Flush(false, false)
+	assert.False(t, ok)
+
+	// at the end of the log the complete statement of backend 2 is emitted
+	line, ok := a.Flush(true, false)
+	assert.True(t, ok)
+	assert.Equal(t, "2024-04-19 10:12:16.890 CEST [2] LOG:  statement: select 1\n", line)
+	_, ok = a.Flush(true, false)
+	assert.False(t, ok)
+
+	// statements are emitted in order once their wait is over
+	for _, e := range a.entries {
+		e.read = e.read.Add(-2 * detailTimeout)
+	}
+	line, _ = a.Flush(true, false)
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [1] LOG:  execute <unnamed>: select * from job where id=$1\n", line)
+	line, _ = a.Flush(true, false)
+	assert.Equal(t, "2024-04-19 10:12:16.891 CEST [3] LOG:  statement: select 2\n", line)
+}
+
+func TestLastMessageWaitsForContinuationLines(t *testing.T) {
+	a := &assembler{}
+	a.Add("2024-04-19 10:12:16.889 CEST [1] LOG:  statement: select *\n")
+	_, ok := a.Flush(true, false)
+	assert.False(t, ok)
+	a.last.read = a.last.read.Add(-2 * detailTimeout)
+
+	// continuation lines restart the wait
+	a.Add("\tfrom job\n")
+	_, ok = a.Flush(true, false)
+	assert.False(t, ok)
+	a.last.read = a.last.read.Add(-2 * detailTimeout)
+	line, ok := a.Flush(true, false)
+	assert.True(t, ok)
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [1] LOG:  statement: select * from job\n", line)
+}
+
+func nextLine(t *testing.T, l Log) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
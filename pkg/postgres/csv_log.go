@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Columns of the PostgreSQL csvlog format. Newer versions append further
+// columns, which are ignored.
+const (
+	csvLogTime = iota
+	csvUserName
+	csvDatabaseName
+	csvProcessID
+	_ // connection_from
+	_ // session_id
+	_ // session_line_num
+	_ // command_tag
+	_ // session_start_time
+	_ // virtual_transaction_id
+	_ // transaction_id
+	csvErrorSeverity
+	_ // sql_state_code
+	csvMessage
+	csvDetail
+)
+
+// CSVLog reads PostgreSQL logs written in csvlog format:
+//
+//	log_destination = 'csvlog'
+//
+// Each CSV record, including records with quoted multi-line messages, is
+// returned as a single line. Bind parameters of the detail field are bound to
+// the placeholders of the statement. The lines have the layout of the stderr
+// format, thus their timestamps are parsed by Log.Timestamp:
+//
+//	2024-04-19 10:12:16.889 CEST [89718] jobs@jobs LOG:  execute <unnamed>: insert into job (title, id) values ('Hello', '1')
+type CSVLog struct {
+	Log
+	record *csvRecord // record assembled from the lines read so far
+}
+
+// NewCSVLog opens the csvlog logfileName, see NewPostgresLog.
+func NewCSVLog(logfileName string, follow string) (*CSVLog, error) {
+	l, err := NewPostgresLog(logfileName, follow)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVLog{Log: l, record: &csvRecord{}}, nil
+}
+
+// NewStaticCSVLog creates a log that reads the finished csvlog r.
+func NewStaticCSVLog(r io.Reader) *CSVLog {
+	return &CSVLog{Log: NewStaticLog(r), record: &csvRecord{}}
+}
+
+// NextLine reads the next CSV record and returns it as single line. Waits until
+// the record was written completely or done is closed, static logs return
+// io.EOF at their end instead.
+func (l *CSVLog) NextLine(done chan struct{}) (string, error) {
+	return l.LineReader.NextLine(done, l.record)
+}
+
+// csvRecord assembles a CSV record from its physical lines.
+type csvRecord struct {
+	pending string // incomplete record read so far
+}
+
+// Add adds the physical line read from the log. Returns the record as single
+// line once it is complete.
+func (r *csvRecord) Add(line string) (string, bool) {
+	// quoted fields may contain line breaks, a record is complete once all
+	// quotes are closed
+	r.pending += line
+	if strings.Count(r.pending, `"`)%2 != 0 {
+		return "", false
+	}
+	return r.flush()
+}
+
+// Flush returns the pending record at the end of a static log, whose last
+// record may lack its line break.
+func (r *csvRecord) Flush(_ bool, end bool) (string, bool) {
+	if !end || r.pending == "" {
+		return "", false
+	}
+	return r.flush()
+}
+
+func (r *csvRecord) flush() (string, bool) {
+	record := r.pending
+	r.pending = ""
+	return parseCSVRecord(record)
+}
+
+// parseCSVRecord returns the record as single line. Returns false if record
+// isn't a valid csvlog record.
+func parseCSVRecord(record string) (string, bool) {
+	r := csv.NewReader(strings.NewReader(record))
+	r.FieldsPerRecord = -1
+	fields, err := r.Read()
+	if err != nil || len(fields) <= csvDetail {
+		log.Printf("skipping invalid csvlog record: %s", strings.TrimSpace(record))
+		return "", false
+	}
+	message := bind(fields[csvMessage], fields[csvDetail])
+	message = singleLine(message)
+	return fmt.Sprintf("%s [%s] %s@%s %s:  %s\n", fields[csvLogTime], fields[csvProcessID],
+		fields[csvUserName], fields[csvDatabaseName], fields[csvErrorSeverity], message), true
+}
+
+// singleLine replaces the line breaks of multi-line statements by spaces.
+func singleLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+}
+
+var (
+	parameterRegex   = regexp.MustCompile(`\$(\d+) = ('(?:[^']|'')*'|NULL)`)
+	placeholderRegex = regexp.MustCompile(`\$\d+`)
+)
+
+// bind replaces the placeholders $1, $2, ... of statement with the parameters
+// listed in detail, e.g. "parameters: $1 = 'World', $2 = NULL".
+func bind(statement string, detail string) string {
+	if !strings.HasPrefix(detail, "parameters:") {
+		return statement
+	}
+	values := make(map[string]string)
+	for _, m := range parameterRegex.FindAllStringSubmatch(detail, -1) {
+		values["$"+m[1]] = m[2]
+	}
+	return placeholderRegex.ReplaceAllStringFunc(statement, func(p string) string {
+		if v, ok := values[p]; ok {
+			return v
+		}
+		return p
+	})
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVReadLine(t *testing.T) {
+	l, err := NewCSVLog("postgres.csv", df.FollowPoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	actual, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	expected := "2024-04-19 10:12:16.889 CEST [89718] jobs@jobs LOG:  execute <unnamed>: insert into job (description, publish_at, publish_trials, published_timestamp, tags, title, id) values ('World', '2024-04-19 10:12:12', '0', NULL, '', 'Hello', '1', '8', '9', 'ten')\n"
+	assert.Equal(t, expected, actual)
+}
+
+func TestCSVReadMultiLineRecord(t *testing.T) {
+	l, err := NewCSVLog("postgres.csv", df.FollowPoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, _ = l.NextLine(nil)
+	actual, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-19 10:12:18.541 CEST [89718] jobs@jobs LOG:  statement: select * from job where title = 'Say \"Hello\"'\n", actual)
+}
+
+func TestCSVStaticLogEndsWithEOF(t *testing.T) {
+	l := NewStaticCSVLog(strings.NewReader(`2024-04-19 10:12:16.889 CEST,"jobs","jobs",89718,,,,,,,,LOG,00000,"statement: select 1",`))
+	actual, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-19 10:12:16.889 CEST [89718] jobs@jobs LOG:  statement: select 1\n", actual)
+	_, err = l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCSVTimestampInUTC(t *testing.T) {
+	actual, err := (&CSVLog{}).Timestamp("2024-04-19 10:12:16.889 UTC [89718] jobs@jobs LOG:  statement: select 1")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 4, 19, 10, 12, 16, 889000000, time.UTC), actual)
+}
+
+func TestCSVTimestamp(t *testing.T) {
+	actual, err := (&CSVLog{}).Timestamp("2024-04-19 10:12:16.889 CEST [89718] jobs@jobs LOG:  statement: select 1")
+	assert.Nil(t, err)
+	expected, _ := time.ParseInLocation(time.DateTime, "2024-04-19 10:12:16.889", time.Local)
+	assert.Equal(t, expected.UTC(), actual)
+}
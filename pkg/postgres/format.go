@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name:       "postgres",
+		LogFactory: func(channel df.Channel) df.LogFactory { return LogFactory{Follow: channel.Follow} },
+		Tokenizer:  Tokenizer{},
+	})
+	df.RegisterFormat(df.Format{
+		Name:       "postgres-csv",
+		LogFactory: func(channel df.Channel) df.LogFactory { return CSVLogFactory{Follow: channel.Follow} },
+		Tokenizer:  Tokenizer{},
+	})
+	df.RegisterFormat(df.Format{
+		Name:       "postgres-json",
+		LogFactory: func(channel df.Channel) df.LogFactory { return JSONLogFactory{Follow: channel.Follow} },
+		Tokenizer:  JSONTokenizer{},
+	})
+	df.RegisterFormat(df.Format{
+		Name:       "postgres-logical",
+		LogFactory: func(channel df.Channel) df.LogFactory { return LogicalLogFactory{Follow: channel.Follow} },
+		Tokenizer:  LogicalTokenizer{},
+	})
+}
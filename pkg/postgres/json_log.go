@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// jsonRecord holds the fields of a PostgreSQL jsonlog record used by datafrog.
+type jsonRecord struct {
+	Timestamp     string `json:"timestamp"`
+	User          string `json:"user,omitempty"`
+	DBName        string `json:"dbname,omitempty"`
+	PID           int    `json:"pid,omitempty"`
+	ErrorSeverity string `json:"error_severity,omitempty"`
+	Message       string `json:"message,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Statement     string `json:"statement,omitempty"`
+}
+
+// statement returns the message of r or its statement field if r has no
+// message.
+func (r jsonRecord) statement() string {
+	if r.Message == "" {
+		return r.Statement
+	}
+	return r.Message
+}
+
+// JSONLog reads PostgreSQL logs written in jsonlog format (PostgreSQL 15+):
+//
+//	log_destination = 'jsonlog'
+//
+// NextLine returns one json record per line. Bind parameters of the detail
+// field are already bound to the placeholders of the message. Records are
+// tokenized by JSONTokenizer.
+type JSONLog struct {
+	Log
+}
+
+// NewJSONLog opens the jsonlog logfileName, see NewPostgresLog.
+func NewJSONLog(logfileName string, follow string) (JSONLog, error) {
+	l, err := NewPostgresLog(logfileName, follow)
+	if err != nil {
+		return JSONLog{}, err
+	}
+	return JSONLog{Log: l}, nil
+}
+
+// NewStaticJSONLog creates a log that reads the finished jsonlog r.
+func NewStaticJSONLog(r io.Reader) JSONLog {
+	return JSONLog{Log: NewStaticLog(r)}
+}
+
+// Timestamp returns the timestamp field of the json record s in UTC, see
+// parseTime.
+func (l JSONLog) Timestamp(s string) (time.Time, error) {
+	var r jsonRecord
+	if err := json.Unmarshal([]byte(s), &r); err != nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	return parseTime(r.Timestamp, time.Local)
+}
+
+// NextLine reads the next json record. Waits until a new record becomes
+// available or done is closed, static logs return io.EOF at their end instead.
+func (l JSONLog) NextLine(done chan struct{}) (string, error) {
+	return l.LineReader.NextLine(done, df.Parser(parseJSONRecord))
+}
+
+// parseJSONRecord binds the parameters of the record's detail field to its
+// message and returns the record as single line. Returns false if line isn't a
+// valid jsonlog record.
+func parseJSONRecord(line string) (string, bool) {
+	var r jsonRecord
+	if err := json.Unmarshal([]byte(line), &r); err != nil {
+		log.Printf("skipping invalid jsonlog record: %s", strings.TrimSpace(line))
+		return "", false
+	}
+	r.Message = singleLine(bind(r.Message, r.Detail))
+	r.Statement = singleLine(r.Statement)
+
+	// keep <unnamed> et al. readable for patterns
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(r); err != nil {
+		return "", false
+	}
+	return b.String(), true
+}
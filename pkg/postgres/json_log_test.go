@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONReadLine(t *testing.T) {
+	jl, err := NewJSONLog("postgres.json", df.FollowPoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jl.Close()
+	actual, err := jl.NextLine(nil)
+	assert.Nil(t, err)
+	expected := `{"timestamp":"2024-04-19 10:12:16.889 CEST","user":"jobs","dbname":"jobs","pid":89718,"error_severity":"LOG","message":"execute <unnamed>: insert into job (description, publish_at, publish_trials, published_timestamp, tags, title, id) values ('World', '2024-04-19 10:12:12', '0', NULL, '', 'Hello', '1')","detail":"parameters: $1 = 'World', $2 = '2024-04-19 10:12:12', $3 = '0', $4 = NULL, $5 = '', $6 = 'Hello', $7 = '1'"}` + "\n"
+	assert.Equal(t, expected, actual)
+}
+
+func TestJSONTimestamp(t *testing.T) {
+	actual, err := JSONLog{}.Timestamp(`{"timestamp":"2024-04-19 10:12:16.889 CEST","message":"statement: select 1"}`)
+	assert.Nil(t, err)
+	expected, _ := time.ParseInLocation(time.DateTime, "2024-04-19 10:12:16.889", time.Local)
+	assert.Equal(t, expected.UTC(), actual)
+}
+
+func TestJSONTimestampInUTC(t *testing.T) {
+	actual, err := JSONLog{}.Timestamp(`{"timestamp":"2024-04-19 10:12:16.889 UTC","message":"statement: select 1"}`)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 4, 19, 10, 12, 16, 889000000, time.UTC), actual)
+}
+
+func TestJSONTokenize(t *testing.T) {
+	jl, err := NewJSONLog("postgres.json", df.FollowPoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jl.Close()
+	_, _ = jl.NextLine(nil)
+	line, err := jl.NextLine(nil)
+	assert.Nil(t, err)
+	tokens := JSONTokenizer{}.Tokenize(line, []string{"select"})
+	assert.Equal(t, []string{"select", "*", "from", "job", "where", "title", "=", "Hello"}, tokens)
+}
+
+func TestJSONStaticLogEndsWithEOF(t *testing.T) {
+	jl := NewStaticJSONLog(strings.NewReader(`{"timestamp":"2024-04-19 10:12:16.889 CEST","message":"statement: select 1"}`))
+	_, err := jl.NextLine(nil)
+	assert.Nil(t, err)
+	_, err = jl.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestJSONStatement(t *testing.T) {
+	line := `{"timestamp":"2024-04-19 10:12:16.889 CEST","user":"update_job","dbname":"jobs","message":"execute <unnamed>: select * from job where id = '1'","detail":"parameters: $1 = '1'"}` + "\n"
+	assert.Equal(t, "execute <unnamed>: select * from job where id = '1'", JSONTokenizer{}.Statement(line))
+
+	// patterns don't match fields besides the statement
+	matches, _ := df.MatchesPattern([]string{"update"}, df.Statement(JSONTokenizer{}, line))
+	assert.False(t, matches)
+	matches, _ = df.MatchesPattern([]string{"update"}, df.Statement(df.NormalizingTokenizer{Tokenizer: JSONTokenizer{}}, line))
+	assert.False(t, matches)
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// JSONTokenizer tokenizes the message of json records returned by JSONLog.
+// Records without message are tokenized by their statement field.
+type JSONTokenizer struct {
+}
+
+func (t JSONTokenizer) Tokenize(s string, patterns []string) []string {
+	var r jsonRecord
+	if err := json.Unmarshal([]byte(s), &r); err != nil {
+		return Tokenizer{}.Tokenize(s, patterns)
+	}
+	return df.Tokenize(strings.TrimSpace(df.CutPrefix(r.statement(), patterns)))
+}
+
+// Statement returns the message of the json record s with bound parameters,
+// patterns don't match the other fields like application_name.
+func (t JSONTokenizer) Statement(s string) string {
+	var r jsonRecord
+	if err := json.Unmarshal([]byte(s), &r); err != nil {
+		return s
+	}
+	return r.statement()
+}
@@ -1,109 +1,76 @@
 package postgres
 
 import (
-	"bufio"
 	"errors"
-	"fmt"
 	"io"
-	"log"
-	"os"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/rwirdemann/datafrog/pkg/df"
 )
 
 type Log struct {
-	logfile *os.File
-	reader  *bufio.Reader
+	df.LineReader
+	entries *assembler // statements assembled from the lines read so far
 }
 
-func NewPostgresLog(logfileName string) Log {
-	logfile, err := os.Open(logfileName)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return Log{logfile: logfile, reader: bufio.NewReader(logfile)}
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r), entries: &assembler{}}
 }
 
-func (m Log) Close() {
-	err := m.logfile.Close()
+// NewPostgresLog opens the log logfileName. If the name contains the
+// placeholder YYYY-MM-DD_hhmmss the newest matching file with the same
+// extension is opened, and files that are created later by the server's log
+// rotation are picked up. The
+// log is followed across rotation and truncation according to the follow mode.
+func NewPostgresLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, resolveDate)
 	if err != nil {
-		log.Fatal(err)
+		return Log{}, err
 	}
+	return Log{LineReader: reader, entries: &assembler{}}, nil
 }
 
+// timestampRegex matches the timestamps of the log_line_prefix escapes %m and
+// %t followed by their time zone, e.g. "2024-04-19 10:12:16.889 CEST".
+var timestampRegex = regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2}\s[0-9]{2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]+)?\s\S+`)
+
+// Timestamp returns the timestamp of s in UTC, see parseTime.
 func (m Log) Timestamp(s string) (time.Time, error) {
-	t, err := df.Timestamp(s, "[0-9]{4}-[0-9]{2}-[0-9]{2}\\s[0-9]{2}:[0-9]{2}:[0-9]{2}\\.[0-9]{3}", time.DateTime)
-	if err != nil {
+	ts := timestampRegex.FindString(s)
+	if ts == "" {
 		return time.Time{}, errors.New("string contains no valid Timestamp")
 	}
-	return t, nil
+	return parseTime(ts, time.Local)
 }
 
-// Tail sets the read cursor of the log file to its end.
-func (m Log) Tail() error {
-	log.Printf("tailing %s...", m.logfile.Name())
-	defer log.Printf("tailing successful!")
-	for {
-		_, err := m.reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			} else {
-				return err
-			}
+// parseTime parses the timestamp s logged with its time zone and returns it in
+// UTC. The zone is either numeric like +02 or an abbreviation like UTC or CEST,
+// that is resolved in loc, the local time zone of datafrog. Timestamps with an
+// abbreviation unknown to loc are taken as local time of loc.
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05 -07", "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05 -07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
 		}
 	}
-}
-
-func (m Log) NextLine(done chan struct{}) (string, error) {
-	for {
-		select {
-		default:
-			line, err := m.reader.ReadString('\n')
-			if err != nil {
-				if err == io.EOF {
-					time.Sleep(500 * time.Millisecond)
-					continue
-				}
-				return "", err
-			}
-
-			matches := strings.Contains(line, "$1")
-			if matches {
-				line = m.mergeNext(line)
-			}
-
-			return line, nil
-		case <-done:
-			log.Printf("nextline: done channel closed")
-			return "", nil
-		}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05 MST", s, loc)
+	if err != nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	if zone, _ := t.Zone(); t.Location() != loc && t.Location() != time.UTC && zone != "GMT" {
+		// unknown abbreviations get a made up zone without offset
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
 	}
+	return t.UTC(), nil
 }
 
-func (m Log) mergeNext(line string) string {
-	next, _ := m.reader.ReadString('\n')
-	if strings.Contains(next, "DETAIL") {
-		values := make(map[int]string)
-		r := regexp.MustCompile(`\$\d\s=\s'(?:[^']|'')*'|\$\d\s=\sNULL`)
-		matches := r.FindAllStringSubmatch(next, -1)
-		for i, v := range matches {
-			split := strings.Split(v[0], "=")
-			values[i] = strings.Trim(split[len(split)-1], " ")
-		}
-		i := 1
-		for {
-			placeholder := fmt.Sprintf("$%d", i)
-			if strings.Contains(line, placeholder) {
-				line = strings.Replace(line, placeholder, values[i-1], -1)
-				i = i + 1
-			} else {
-				break
-			}
-		}
-	}
-	return line
+// NextLine returns the next complete statement. Continuation lines are joined
+// and bind parameters of DETAIL lines are bound to the statement of the same
+// backend. Waits until a statement becomes available or done is closed, static
+// logs return io.EOF at their end instead.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, m.entries)
 }
@@ -1,65 +1,116 @@
 package postgres
 
 import (
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/rwirdemann/datafrog/pkg/df"
 )
 
 type LogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
 }
 
 func (f LogFactory) Create(filename string) (df.Log, error) {
-	logFilePath, err := resolveDate(filename)
+	l, err := NewPostgresLog(filename, f.Follow)
 	if err != nil {
-		log.Fatalf("LogFactory: Could not resolve Log-File %s: %s", filename, err)
+		log.Printf("LogFactory: Could not resolve Log-File %s: %s", filename, err)
+		return nil, err
 	}
-	return NewPostgresLog(logFilePath), err
+	return l, nil
 }
 
-func resolveDate(filename string) (string, error) {
+// datePlaceholder stands for the timestamp postgres writes into the names of
+// its log files, e.g. postgresql-YYYY-MM-DD_hhmmss.log.
+const datePlaceholder = "YYYY-MM-DD_hhmmss"
 
-	var dateString = "YYYY-MM-DD_hhmmss.log"
-	if !strings.Contains(filename, dateString) {
+// resolveDate returns the newest file of the series of log files filename if
+// it contains datePlaceholder, and filename otherwise. Files of a series share
+// the prefix and the extension of filename, e.g. .log, .csv or .json, and their
+// timestamped names sort in the order the files were created.
+func resolveDate(filename string) (string, error) {
+	base := filepath.Base(filename)
+	idx := strings.Index(base, datePlaceholder)
+	if idx < 0 {
 		return filename, nil
 	}
 
-	// Logfile name contains a timestamp: Find the newest one in containing folder
-
 	path := filepath.Dir(filename)
-
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return filename, err
 	}
 
-	var expectedFileNameStart string = filename[len(path)+1 : len(filename)-len(dateString)]
-	var logFilePath = filename
-	var newestTime int64 = 0
-	var found = false
+	series := regexp.MustCompile("^" + regexp.QuoteMeta(base[:idx]) + `\d{4}-\d{2}-\d{2}_\d{6}` +
+		regexp.QuoteMeta(base[idx+len(datePlaceholder):]) + "$")
+	newest := ""
 	for _, file := range entries {
-		// First check, if the name matches
-		if strings.Contains(file.Name(), expectedFileNameStart) {
-			// Now check, if it is the newest
-			fi, err := os.Stat(path + "/" + file.Name())
-			if err == nil {
-				currTime := fi.ModTime().Unix()
-				if currTime > newestTime {
-					found = true
-					newestTime = currTime
-					logFilePath = path + "/" + file.Name()
-				}
-			}
+		if !file.IsDir() && series.MatchString(file.Name()) && file.Name() > newest {
+			newest = file.Name()
 		}
 	}
+	if newest == "" {
+		return filename, fs.ErrNotExist
+	}
+	return filepath.Join(path, newest), nil
+}
+
+func (f LogFactory) Static(r io.Reader) df.Log {
+	return NewStaticLog(r)
+}
 
-	err = nil
-	if !found {
-		err = fs.ErrNotExist
+// CSVLogFactory creates logs of the postgres-csv format.
+type CSVLogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f CSVLogFactory) Create(filename string) (df.Log, error) {
+	l, err := NewCSVLog(filename, f.Follow)
+	if err != nil {
+		return nil, err
 	}
-	return logFilePath, err
+	return l, nil
+}
+
+func (f CSVLogFactory) Static(r io.Reader) df.Log {
+	return NewStaticCSVLog(r)
+}
+
+// JSONLogFactory creates logs of the postgres-json format.
+type JSONLogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f JSONLogFactory) Create(filename string) (df.Log, error) {
+	l, err := NewJSONLog(filename, f.Follow)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (f JSONLogFactory) Static(r io.Reader) df.Log {
+	return NewStaticJSONLog(r)
+}
+
+// LogicalLogFactory creates logs of the postgres-logical format.
+type LogicalLogFactory struct {
+	Follow string // follow mode of created logs, see df.FollowNotify
+}
+
+func (f LogicalLogFactory) Create(filename string) (df.Log, error) {
+	l, err := NewLogicalLog(filename, f.Follow)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (f LogicalLogFactory) Static(r io.Reader) df.Log {
+	return NewStaticLogicalLog(r)
 }
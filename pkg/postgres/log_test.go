@@ -2,10 +2,14 @@ package postgres
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 	"time"
 
+	"github.com/rwirdemann/datafrog/pkg/df"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,7 +22,10 @@ func TestRegex(t *testing.T) {
 }
 
 func TestReadLine(t *testing.T) {
-	pl := NewPostgresLog("postgres.log")
+	pl, err := NewPostgresLog("postgres.log", df.FollowPoll)
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer pl.Close()
 	actual := readLine(t, pl)
 	expected := "2024-04-19 10:12:16.889 CEST [89718] LOG:  execute <unnamed>: insert into job (description, publish_at, publish_trials, published_timestamp, tags, title, id) values ('World', '2024-04-19 10:12:12', '0', NULL, '', 'Hello', '1')\n"
@@ -27,12 +34,37 @@ func TestReadLine(t *testing.T) {
 
 func TestPostgresTimestamp(t *testing.T) {
 	pl := Log{}
-	actual, err := pl.Timestamp("2024-04-19 10:12:16.889 CEST [89718] LOG:  execute <unnamed>: insert into job (description, publish_at, publish_trials, published_timestamp, tags, title, id) values ('World', '2024-04-19 10:12:12', '0', NULL, '', 'Hello', '1')")
+	actual, err := pl.Timestamp("2024-04-19 10:12:16.889 UTC [89718] LOG:  execute <unnamed>: insert into job (description, publish_at, publish_trials, published_timestamp, tags, title, id) values ('World', '2024-04-19 10:12:12', '0', NULL, '', 'Hello', '1')")
 	assert.Nil(t, err)
 	expected, _ := time.Parse(time.DateTime, "2024-04-19 10:12:16.889")
 	assert.Equal(t, expected, actual)
 }
 
+func TestParseTimeWithZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	tests := []struct {
+		s        string
+		expected time.Time
+	}{
+		{"2024-04-19 10:12:16.889 UTC", time.Date(2024, 4, 19, 10, 12, 16, 889000000, time.UTC)},
+		{"2024-04-19 10:12:16.889 CEST", time.Date(2024, 4, 19, 8, 12, 16, 889000000, time.UTC)},
+		{"2024-01-19 10:12:16.889 CET", time.Date(2024, 1, 19, 9, 12, 16, 889000000, time.UTC)},
+		{"2024-04-19 10:12:16 CEST", time.Date(2024, 4, 19, 8, 12, 16, 0, time.UTC)},
+		{"2024-04-19 10:12:16.889 +02", time.Date(2024, 4, 19, 8, 12, 16, 889000000, time.UTC)},
+		{"2024-04-19 10:12:16.889 -0330", time.Date(2024, 4, 19, 13, 42, 16, 889000000, time.UTC)},
+		// unknown abbreviations are taken as local time
+		{"2024-04-19 10:12:16.889 EDT", time.Date(2024, 4, 19, 8, 12, 16, 889000000, time.UTC)},
+	}
+	for _, test := range tests {
+		actual, err := parseTime(test.s, berlin)
+		assert.Nil(t, err, test.s)
+		assert.Equal(t, test.expected, actual, test.s)
+	}
+}
+
 func readLine(t *testing.T, pl Log) string {
 	l, err := pl.NextLine(nil)
 	if err != nil {
@@ -40,3 +72,39 @@ func readLine(t *testing.T, pl Log) string {
 	}
 	return l
 }
+
+func TestResolveDatePicksNewestFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"postgresql-2024-04-19_101200.log", "postgresql-2024-04-19_111200.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	_ = os.Chtimes(filepath.Join(dir, "postgresql-2024-04-19_101200.log"), now, now)
+	_ = os.Chtimes(filepath.Join(dir, "postgresql-2024-04-19_111200.log"), now, now)
+
+	actual, err := resolveDate(filepath.Join(dir, "postgresql-YYYY-MM-DD_hhmmss.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "postgresql-2024-04-19_111200.log"), actual)
+}
+
+func TestResolveDateMatchesExtension(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"postgresql-2024-04-19_101200.log", "postgresql-2024-04-19_101200.csv", "postgresql-2024-04-19_111200.csv", "postgresql-2024-04-19_111200.log.1"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := resolveDate(filepath.Join(dir, "postgresql-YYYY-MM-DD_hhmmss.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "postgresql-2024-04-19_101200.log"), actual)
+
+	actual, err = resolveDate(filepath.Join(dir, "postgresql-YYYY-MM-DD_hhmmss.csv"))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "postgresql-2024-04-19_111200.csv"), actual)
+
+	_, err = resolveDate(filepath.Join(dir, "postgresql-YYYY-MM-DD_hhmmss.json"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
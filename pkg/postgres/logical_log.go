@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// LogicalLog reads the output of logical decoding written by pg_recvlogical,
+// e.g.:
+//
+//	pg_recvlogical -d jobs --slot datafrog --start -o include-timestamp=on -f changes.log
+//
+// The output plugins test_decoding and wal2json (format-version 1 and 2) are
+// supported. Each changed row is returned as single line that contains the
+// commit time of its transaction in UTC, the transaction id, the operation, the
+// table and the row as statement-like text:
+//
+//	2024-04-08T10:50:59.123456Z	529 INSERT public.job	insert into public.job id=5 description='World'
+//	2024-04-08T10:50:59.123456Z	529 UPDATE public.job	update public.job where id=5 set id=5 description='Hello'
+//	2024-04-08T10:50:59.123456Z	529 DELETE public.job	delete from public.job where id=5
+//
+// Rows are returned when their transaction commits. Transactions without commit
+// timestamp get the time they were read.
+type LogicalLog struct {
+	Log
+	tx *transaction
+}
+
+// NewLogicalLog opens logfileName that is written by pg_recvlogical, see
+// NewPostgresLog.
+func NewLogicalLog(logfileName string, follow string) (*LogicalLog, error) {
+	l, err := NewPostgresLog(logfileName, follow)
+	if err != nil {
+		return nil, err
+	}
+	return &LogicalLog{Log: l, tx: &transaction{}}, nil
+}
+
+// NewStaticLogicalLog creates a log that reads the finished pg_recvlogical
+// output r. Changes of transactions that weren't committed at the end of r are
+// dropped.
+func NewStaticLogicalLog(r io.Reader) *LogicalLog {
+	return &LogicalLog{Log: NewStaticLog(r), tx: &transaction{}}
+}
+
+// Timestamp returns the commit time of a row returned by NextLine.
+func (l *LogicalLog) Timestamp(s string) (time.Time, error) {
+	c, err := ParseChange(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.Time, nil
+}
+
+// NextLine returns the next changed row of a committed transaction. Waits until
+// a new row becomes available or done is closed, static logs return io.EOF at
+// their end instead.
+func (l *LogicalLog) NextLine(done chan struct{}) (string, error) {
+	return l.LineReader.NextLine(done, l.tx)
+}
+
+// Change is a single changed row returned by LogicalLog.NextLine.
+type Change struct {
+	Time      time.Time
+	XID       string // transaction id, 0 if unknown
+	Operation string // INSERT, UPDATE, DELETE or TRUNCATE
+	Table     string // qualified table name, e.g. public.job
+	Statement string // statement-like text of the row
+}
+
+// ParseChange parses a row returned by LogicalLog.NextLine.
+func ParseChange(s string) (Change, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return Change{}, errors.New("string contains no change")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Change{}, errors.New("string contains no valid Timestamp")
+	}
+	meta := strings.SplitN(fields[1], " ", 3)
+	if len(meta) != 3 {
+		return Change{}, errors.New("string contains no change")
+	}
+	return Change{Time: t, XID: meta[0], Operation: meta[1], Table: meta[2], Statement: fields[2]}, nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogicalTestDecoding(t *testing.T) {
+	l := NewStaticLogicalLog(strings.NewReader("BEGIN 529\n" +
+		"table public.job: INSERT: id[integer]:5 description[character varying]:'O''Reilly Dev' tags[text[]]:'{a,b}' published[timestamp without time zone]:null\n" +
+		"table public.job: UPDATE: old-key: id[integer]:5 new-tuple: id[integer]:6 description[character varying]:'World'\n" +
+		"table public.job: UPDATE: id[integer]:6 description[character varying]:'Hello'\n" +
+		"table public.job: DELETE: id[integer]:6\n" +
+		"table public.application: DELETE: (no-tuple-data)\n" +
+		"COMMIT 529 (at 2024-04-08 12:50:59.123456+02)\n" +
+		"BEGIN 530\n" +
+		"table public.job: INSERT: id[integer]:7\n"))
+	const prefix = "2024-04-08T10:50:59.123456Z\t529 "
+	assert.Equal(t, prefix+"INSERT public.job\tinsert into public.job id=5 description='O''Reilly Dev' tags='{a,b}' published=null\n", logicalLine(t, l))
+	assert.Equal(t, prefix+"UPDATE public.job\tupdate public.job where id=5 set id=6 description='World'\n", logicalLine(t, l))
+	assert.Equal(t, prefix+"UPDATE public.job\tupdate public.job set id=6 description='Hello'\n", logicalLine(t, l))
+	assert.Equal(t, prefix+"DELETE public.job\tdelete from public.job where id=6\n", logicalLine(t, l))
+	assert.Equal(t, prefix+"DELETE public.application\tdelete from public.application\n", logicalLine(t, l))
+
+	// uncommitted transactions are dropped
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLogicalTestDecodingLineBreaks(t *testing.T) {
+	l := NewStaticLogicalLog(strings.NewReader("BEGIN 529\n" +
+		"table public.job: INSERT: description[text]:'line1\nline2' title[text]:'x'\n" +
+		"table public.job: INSERT: description[text]:'{a,b}\n{c}\n' title[text]:'O''Reilly'\n" +
+		"COMMIT 529 (at 2024-04-08 12:50:59.123456+02)\n"))
+	const prefix = "2024-04-08T10:50:59.123456Z\t529 INSERT public.job\t"
+	assert.Equal(t, prefix+`insert into public.job description='line1\nline2' title='x'`+"\n", logicalLine(t, l))
+	assert.Equal(t, prefix+`insert into public.job description='{a,b}\n{c}\n' title='O''Reilly'`+"\n", logicalLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLogicalWal2JSON(t *testing.T) {
+	l := NewStaticLogicalLog(strings.NewReader(`{"xid":529,"timestamp":"2024-04-08 12:50:59.123456+02","change":[
+{"kind":"insert","schema":"public","table":"job","columnnames":["id","description"],"columntypes":["integer","text"],"columnvalues":[5,"World"]}
+,{"kind":"delete","schema":"public","table":"job","oldkeys":{"keynames":["id"],"keytypes":["integer"],"keyvalues":[5]}}
+]}
+{"action":"B","xid":530}
+{"action":"U","xid":530,"schema":"public","table":"job","columns":[{"name":"id","type":"integer","value":6},{"name":"title","type":"text","value":"Go Dev"}],"identity":[{"name":"id","type":"integer","value":6}]}
+{"action":"C","xid":530,"timestamp":"2024-04-08 12:51:00+02"}
+`))
+	assert.Equal(t, "2024-04-08T10:50:59.123456Z\t529 INSERT public.job\tinsert into public.job id=5 description='World'\n", logicalLine(t, l))
+	assert.Equal(t, "2024-04-08T10:50:59.123456Z\t529 DELETE public.job\tdelete from public.job where id=5\n", logicalLine(t, l))
+	assert.Equal(t, "2024-04-08T10:51:00Z\t530 UPDATE public.job\tupdate public.job where id=6 set id=6 title='Go Dev'\n", logicalLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLogicalTokenize(t *testing.T) {
+	s := "2024-04-08T10:50:59.123456Z\t529 INSERT public.job\tinsert into public.job id=5 description='Java Dev'\n"
+	assert.Equal(t, []string{"insert", "into", "public.job", "id=5", "description=Java Dev"}, LogicalTokenizer{}.Tokenize(s, []string{"insert into public.job"}))
+
+	c, err := ParseChange(s)
+	assert.NoError(t, err)
+	assert.Equal(t, Change{Time: time.Date(2024, 4, 8, 10, 50, 59, 123456000, time.UTC), XID: "529", Operation: "INSERT", Table: "public.job",
+		Statement: "insert into public.job id=5 description='Java Dev'"}, c)
+}
+
+func logicalLine(t *testing.T, l *LogicalLog) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
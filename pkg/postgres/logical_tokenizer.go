@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// LogicalTokenizer tokenizes the rows returned by LogicalLog. The tokens
+// contain the operation, the table and one token per column, e.g. ["insert",
+// "into", "public.job", "id=5", "description=World"]. Thus columns that differ
+// between runs, like generated ids, are learned as ignored diffs.
+type LogicalTokenizer struct {
+}
+
+func (t LogicalTokenizer) Tokenize(s string, patterns []string) []string {
+	if c, err := ParseChange(s); err == nil {
+		s = c.Statement
+	}
+	return df.Tokenize(df.CutPrefix(s, patterns))
+}
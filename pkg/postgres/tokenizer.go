@@ -5,12 +5,13 @@ import (
 	"strings"
 )
 
-// Tokenizer tokenizes PostgreSQL log entries. PostgreSQL configuration
-// settings:
+// Tokenizer tokenizes PostgreSQL log entries of the stderr format and the lines
+// returned by CSVLog. PostgreSQL configuration settings:
 //
 //	log_destination = 'stderr,csvlog'
 //
-// PostgreSQL splits single sql statements into two parts:
+// PostgreSQL splits single sql statements into two parts, which are joined by
+// Log before tokenizing:
 //
 //	1: select * from job where job0_.publish_at<$1 and job0_.publish_trials<$2
 //	2: parameters: $1 = '2024-04-19 10:07:38.543981', $2 = '1'
@@ -18,21 +19,11 @@ type Tokenizer struct {
 }
 
 func (m Tokenizer) Tokenize(s string, patterns []string) []string {
-	return df.Tokenize(normalize(cutPrefix(s, patterns), patterns))
+	return df.Tokenize(normalize(df.CutPrefix(s, patterns), patterns))
 }
 
 func normalize(s string, patterns []string) string {
-	result := cutPrefix(s, patterns)
+	result := df.CutPrefix(s, patterns)
 	result = strings.TrimSuffix(result, "\n")
 	return result
 }
-
-func cutPrefix(s string, patterns []string) string {
-	for _, p := range patterns {
-		idx := strings.Index(s, df.NewPattern(p).Include)
-		if idx > -1 {
-			return s[idx:]
-		}
-	}
-	return s
-}
@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// testDecodingChangeRegex matches the changes of the test_decoding plugin, e.g.
+	// "table public.job: INSERT: id[integer]:5 description[character varying]:'World'"
+	testDecodingChangeRegex = regexp.MustCompile(`^table (.+?): (INSERT|UPDATE|DELETE|TRUNCATE):\s?(.*)$`)
+
+	// testDecodingCommitRegex matches the commit of a transaction, the
+	// timestamp requires the option include-timestamp=on, e.g.
+	// "COMMIT 529 (at 2024-04-08 12:50:59.123456+02)"
+	testDecodingCommitRegex = regexp.MustCompile(`^COMMIT(?: (\d+))?(?: \(at (.+)\))?$`)
+)
+
+// commitTimeLayouts are the layouts of the commit timestamps of test_decoding
+// and wal2json.
+var commitTimeLayouts = []string{"2006-01-02 15:04:05.999999999-07", "2006-01-02 15:04:05.999999999-07:00"}
+
+// change is a changed row of a transaction that isn't yet committed.
+type change struct {
+	operation string
+	table     string
+	statement string
+}
+
+// transaction assembles the changes of a transaction from the lines written by
+// pg_recvlogical. The changes are returned by next after the transaction was
+// committed.
+type transaction struct {
+	xid      string
+	changes  []change
+	document string   // incomplete wal2json document read so far
+	pending  string   // test_decoding change whose quoted value isn't closed yet
+	ready    []string // rows of committed transactions
+}
+
+// add adds the physical line read from the log.
+func (t *transaction) add(line string) {
+	if t.pending != "" {
+		line = t.pending + line
+		t.pending = ""
+	} else if t.document != "" || strings.HasPrefix(strings.TrimSpace(line), "{") {
+		t.addJSON(line)
+		return
+	}
+	// quoted values may contain line breaks, a change is complete once all
+	// quotes are closed
+	if strings.HasPrefix(line, "table ") && strings.Count(line, "'")%2 != 0 {
+		t.pending = line
+		return
+	}
+	line = lineBreaks.Replace(strings.TrimRight(line, "\r\n"))
+	switch {
+	case strings.HasPrefix(line, "BEGIN"):
+		t.begin(strings.TrimSpace(strings.TrimPrefix(line, "BEGIN")))
+	case testDecodingCommitRegex.MatchString(line):
+		m := testDecodingCommitRegex.FindStringSubmatch(line)
+		if m[1] != "" {
+			t.xid = m[1]
+		}
+		t.commit(m[2])
+	case testDecodingChangeRegex.MatchString(line):
+		m := testDecodingChangeRegex.FindStringSubmatch(line)
+		t.changes = append(t.changes, testDecodingChange(m[1], m[2], m[3]))
+	}
+}
+
+// Add adds the physical line read from the log. Returns the next row of a
+// committed transaction.
+func (t *transaction) Add(line string) (string, bool) {
+	t.add(line)
+	return t.next()
+}
+
+// Flush returns the next row of a committed transaction.
+func (t *transaction) Flush(_ bool, _ bool) (string, bool) {
+	return t.next()
+}
+
+// next returns the next row of a committed transaction.
+func (t *transaction) next() (string, bool) {
+	if len(t.ready) == 0 {
+		return "", false
+	}
+	s := t.ready[0]
+	t.ready = t.ready[1:]
+	return s, true
+}
+
+func (t *transaction) begin(xid string) {
+	t.xid = xid
+	t.changes = nil
+}
+
+// commit makes the changes of the transaction available to next.
+func (t *transaction) commit(timestamp string) {
+	ts := time.Now()
+	for _, layout := range commitTimeLayouts {
+		if parsed, err := time.Parse(layout, timestamp); err == nil {
+			ts = parsed
+			break
+		}
+	}
+	xid := t.xid
+	if xid == "" {
+		xid = "0"
+	}
+	for _, c := range t.changes {
+		t.ready = append(t.ready, ts.UTC().Format(time.RFC3339Nano)+"\t"+xid+" "+c.operation+" "+c.table+"\t"+c.statement+"\n")
+	}
+	t.begin("")
+}
+
+// testDecodingChange creates the change of a test_decoding row. Updates contain
+// the old key if the table's replica identity is full or its key changed:
+//
+//	old-key: id[integer]:5 new-tuple: id[integer]:6 description[text]:'World'
+func testDecodingChange(table string, operation string, columns string) change {
+	var where, set []string
+	switch operation {
+	case "INSERT":
+		set = parseColumns(columns)
+	case "UPDATE":
+		if old, tuple, ok := strings.Cut(strings.TrimPrefix(columns, "old-key: "), " new-tuple: "); ok {
+			where = parseColumns(old)
+			set = parseColumns(tuple)
+		} else {
+			set = parseColumns(columns)
+		}
+	case "DELETE":
+		where = parseColumns(columns)
+	}
+	return change{operation: operation, table: table, statement: rowStatement(operation, table, where, set)}
+}
+
+// parseColumns parses the columns "name[type]:value ..." of a test_decoding row
+// and returns them as "name=value". Strings are quoted by single quotes, that
+// are escaped by doubling them. Rows like "(no-tuple-data)" contain no columns.
+func parseColumns(s string) []string {
+	var columns []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		open := strings.Index(s, "[")
+		sep := strings.Index(s, "]:")
+		if open < 0 || sep < open {
+			break
+		}
+		name := s[:open]
+		s = s[sep+2:]
+		end := strings.IndexByte(s, ' ')
+		if strings.HasPrefix(s, "'") {
+			end = 1
+			for end < len(s) && !(s[end] == '\'' && (end+1 == len(s) || s[end+1] != '\'')) {
+				if s[end] == '\'' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(s))
+		}
+		if end < 0 {
+			end = len(s)
+		}
+		columns = append(columns, name+"="+s[:end])
+		s = s[end:]
+	}
+	return columns
+}
+
+// rowStatement returns the statement-like text of a changed row.
+func rowStatement(operation string, table string, where []string, set []string) string {
+	var s []string
+	switch operation {
+	case "INSERT":
+		s = append([]string{"insert into", table}, set...)
+	case "UPDATE":
+		s = []string{"update", table}
+		if len(where) > 0 {
+			s = append(append(s, "where"), where...)
+		}
+		s = append(append(s, "set"), set...)
+	case "DELETE":
+		s = []string{"delete from", table}
+		if len(where) > 0 {
+			s = append(append(s, "where"), where...)
+		}
+	default:
+		s = []string{strings.ToLower(operation), table}
+	}
+	return strings.Join(s, " ")
+}
+
+// wal2jsonColumn is a column of wal2json format-version 2.
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// wal2jsonChange is a change of wal2json format-version 1 or 2.
+type wal2jsonChange struct {
+	Action    string           `json:"action"` // version 2: B, C, I, U, D, T or M
+	Kind      string           `json:"kind"`   // version 1: insert, update or delete
+	XID       json.Number      `json:"xid"`
+	Timestamp string           `json:"timestamp"`
+	Schema    string           `json:"schema"`
+	Table     string           `json:"table"`
+	Columns   []wal2jsonColumn `json:"columns"`
+	Identity  []wal2jsonColumn `json:"identity"`
+
+	ColumnNames  []string          `json:"columnnames"`
+	ColumnValues []json.RawMessage `json:"columnvalues"`
+	OldKeys      struct {
+		KeyNames  []string          `json:"keynames"`
+		KeyValues []json.RawMessage `json:"keyvalues"`
+	} `json:"oldkeys"`
+
+	Change []wal2jsonChange `json:"change"` // version 1: changes of the transaction
+}
+
+// addJSON adds a line of a wal2json document. Documents of format-version 1
+// contain a whole transaction and may span several lines. Documents of
+// format-version 2 contain a single change, begin or commit.
+func (t *transaction) addJSON(line string) {
+	t.document += line
+	var c wal2jsonChange
+	err := json.NewDecoder(strings.NewReader(t.document)).Decode(&c)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return
+	}
+	document := t.document
+	t.document = ""
+	if err != nil {
+		log.Printf("skipping invalid wal2json document: %s", strings.TrimSpace(document))
+		return
+	}
+
+	table := c.Schema + "." + c.Table
+	switch c.Action {
+	case "B":
+		t.begin(c.XID.String())
+	case "C":
+		if c.XID != "" {
+			t.xid = c.XID.String()
+		}
+		t.commit(c.Timestamp)
+	case "I":
+		t.changes = append(t.changes, change{"INSERT", table, rowStatement("INSERT", table, nil, wal2jsonColumns(c.Columns))})
+	case "U":
+		t.changes = append(t.changes, change{"UPDATE", table, rowStatement("UPDATE", table, wal2jsonColumns(c.Identity), wal2jsonColumns(c.Columns))})
+	case "D":
+		t.changes = append(t.changes, change{"DELETE", table, rowStatement("DELETE", table, wal2jsonColumns(c.Identity), nil)})
+	case "T":
+		t.changes = append(t.changes, change{"TRUNCATE", table, rowStatement("TRUNCATE", table, nil, nil)})
+	case "":
+		t.begin(c.XID.String())
+		for _, v1 := range c.Change {
+			table := v1.Schema + "." + v1.Table
+			operation := strings.ToUpper(v1.Kind)
+			where := namedValues(v1.OldKeys.KeyNames, v1.OldKeys.KeyValues)
+			if operation == "INSERT" {
+				where = nil
+			}
+			set := namedValues(v1.ColumnNames, v1.ColumnValues)
+			t.changes = append(t.changes, change{operation, table, rowStatement(operation, table, where, set)})
+		}
+		t.commit(c.Timestamp)
+	}
+}
+
+func wal2jsonColumns(columns []wal2jsonColumn) []string {
+	var s []string
+	for _, c := range columns {
+		s = append(s, c.Name+"="+jsonValue(c.Value))
+	}
+	return s
+}
+
+func namedValues(names []string, values []json.RawMessage) []string {
+	var s []string
+	for i := 0; i < len(names) && i < len(values); i++ {
+		s = append(s, names[i]+"="+jsonValue(values[i]))
+	}
+	return s
+}
+
+// jsonValue formats the json value v like test_decoding: strings are quoted by
+// ', numbers, booleans and null are kept.
+func jsonValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return "'" + strings.ReplaceAll(lineBreaks.Replace(s), "'", "''") + "'"
+	}
+	return string(v)
+}
+
+// lineBreaks escapes the line breaks of values, rows are returned as single
+// line.
+var lineBreaks = strings.NewReplacer("\n", `\n`, "\r", `\r`)
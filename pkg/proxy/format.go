@@ -0,0 +1,58 @@
+// Package proxy implements the http-proxy format, which records the HTTP
+// requests the SUT sends to external services. Channels of this format have no
+// log file: dfgapi runs a proxy configured by a df.ProxyFormat, the SUT sends
+// its requests through the proxy, which returns each request as line of the
+// channel log.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name: "http-proxy",
+		LogFactory: func(channel df.Channel) df.LogFactory {
+			return LogFactory{Format: channel.Proxy}
+		},
+		Tokenizer: Tokenizer{},
+		Validate: func(channel df.Channel) error {
+			_, err := compile(channel.Proxy)
+			return err
+		},
+		Serve: func(channel df.Channel) error {
+			_, err := serve(channel.Proxy)
+			return err
+		},
+	})
+}
+
+// format is the validated df.ProxyFormat.
+type format struct {
+	df.ProxyFormat
+	target *url.URL // nil for forward proxies
+}
+
+func compile(f *df.ProxyFormat) (format, error) {
+	if f == nil {
+		return format{}, errors.New("http-proxy format requires the channel setting proxy")
+	}
+	if f.Listen == "" {
+		return format{}, errors.New("listen address is required")
+	}
+	if f.Target == "" {
+		return format{ProxyFormat: *f}, nil
+	}
+	target, err := url.Parse(f.Target)
+	if err != nil {
+		return format{}, fmt.Errorf("target: %w", err)
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return format{}, fmt.Errorf("target '%s' is no http or https URL", f.Target)
+	}
+	return format{ProxyFormat: *f, target: target}, nil
+}
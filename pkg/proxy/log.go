@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log returns the requests forwarded by a proxy since the log was created.
+// Each request is returned as single line that contains the time in UTC, the
+// address of the SUT, the method, the URL of the external service, the
+// selected headers and the normalized body:
+//
+//	2024-04-08T10:50:59.123Z	127.0.0.1:53124	POST https://api.payment.com/v1/charges header.idempotency-key=4711 body.amount=100 body.currency="EUR"
+type Log struct {
+	server *server
+	queue  *queue // requests not yet returned by NextLine
+	err    error  // logs without proxy, e.g. static logs
+}
+
+// NewProxyLog creates a log that subscribes to the proxy of f. The proxy is
+// started if it isn't yet running.
+func NewProxyLog(f *df.ProxyFormat) (Log, error) {
+	s, err := serve(f)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{server: s, queue: s.subscribe()}, nil
+}
+
+// Tail does nothing, logs return the requests that were forwarded after their
+// creation only.
+func (m Log) Tail() error {
+	return nil
+}
+
+// Close unsubscribes the log from its proxy, that keeps running.
+func (m Log) Close() {
+	if m.server == nil {
+		return
+	}
+	m.server.unsubscribe(m.queue)
+	log.Printf("proxy log of %s closed", m.server.addr)
+}
+
+// Timestamp returns the time of a request returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	ts, _, _ := strings.Cut(s, "\t")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	return t, nil
+}
+
+// NextLine returns the next forwarded request. Waits until the proxy forwards a
+// new request or done is closed. Returns with an empty line and a nil error if
+// the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	for {
+		if s, ok := m.queue.pop(); ok {
+			return s, nil
+		}
+		select {
+		case <-m.queue.signal:
+		case <-done:
+			log.Printf("nextline: done channel closed")
+			return "", nil
+		}
+	}
+}
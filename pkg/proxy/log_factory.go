@@ -0,0 +1,25 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type LogFactory struct {
+	Format *df.ProxyFormat // proxy the created logs subscribe to
+}
+
+// Create subscribes to the proxy of the factory, channels of the http-proxy
+// format have no log file thus filename is ignored.
+func (f LogFactory) Create(filename string) (df.Log, error) {
+	log, err := NewProxyLog(f.Format)
+	return log, err
+}
+
+// Static returns a log that fails, requests are recorded by a running proxy
+// only.
+func (f LogFactory) Static(r io.Reader) df.Log {
+	return Log{err: errors.New("http-proxy channels can't be read from a file")}
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseProxy(t *testing.T) {
+	var received string
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = r.Method + " " + r.URL.String() + " " + string(b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer service.Close()
+
+	l, err := LogFactory{Format: &df.ProxyFormat{Listen: "127.0.0.1:0", Target: service.URL + "/v1", Headers: []string{"Idempotency-Key"}}}.Create("")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "http://"+l.(Log).server.addr+"/charges?expand=customer", strings.NewReader(`{"currency": "EUR", "amount": 100, "tags": ["a b"]}`))
+	r.Header.Set("Idempotency-Key", "4711")
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(r)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `POST /v1/charges?expand=customer {"currency": "EUR", "amount": 100, "tags": ["a b"]}`, received)
+
+	line := nextLine(t, l)
+	request, err := ParseRequest(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", request.Method)
+	assert.Equal(t, service.URL+"/v1/charges?expand=customer", request.URL)
+	assert.Equal(t, []string{"header.idempotency-key=4711", "body.amount=100", `body.currency="EUR"`, `body.tags.0="a b"`}, request.Fields)
+	assert.Contains(t, line, ` 'body.tags.0="a b"'`)
+
+	// other logs of the same proxy settings share the running proxy
+	other, err := LogFactory{Format: &df.ProxyFormat{Listen: "127.0.0.1:0", Target: service.URL + "/v1", Headers: []string{"Idempotency-Key"}}}.Create("")
+	assert.NoError(t, err)
+	other.Close()
+	assert.Same(t, l.(Log).server, other.(Log).server)
+}
+
+func TestForwardProxy(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer service.Close()
+
+	l, err := NewProxyLog(&df.ProxyFormat{Listen: "127.0.0.1:0"})
+	assert.NoError(t, err)
+	defer l.Close()
+
+	proxyURL, _ := url.Parse("http://" + l.server.addr)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	resp, err := client.PostForm(service.URL+"/jobs/5", url.Values{"title": {"Go Dev"}, "company": {"it"}})
+	assert.NoError(t, err)
+	b, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(b))
+
+	request, err := ParseRequest(nextLine(t, l))
+	assert.NoError(t, err)
+	assert.Equal(t, service.URL+"/jobs/5", request.URL)
+	assert.Equal(t, []string{"body.company=it", "body.title=Go Dev"}, request.Fields)
+
+	// queued requests are returned before done is checked
+	_, err = client.Get(service.URL + "/jobs")
+	assert.NoError(t, err)
+	done := make(chan struct{})
+	close(done)
+	line, err := l.NextLine(done)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(line, "GET "+service.URL+"/jobs\n"))
+	line, err = l.NextLine(done)
+	assert.NoError(t, err)
+	assert.Equal(t, "", line)
+}
+
+func TestBodyFields(t *testing.T) {
+	assert.Nil(t, bodyFields("application/json", nil))
+	assert.Equal(t, []string{"body.customer.id=5", "body.items=[]", "body.note=null"}, bodyFields("application/json", []byte(`{"note": null, "items": [], "customer": {"id": 5}}`)))
+	assert.Equal(t, []string{`body=<job id="5"/>\n`}, bodyFields("text/xml", []byte("<job id=\"5\"/>\n")))
+	assert.Equal(t, []string{"body=<2 bytes>"}, bodyFields("application/octet-stream", []byte{0xff, 0xfe}))
+	assert.Equal(t, []string{`body.note=a\r\nb`, "body.title=Go Dev"}, bodyFields("application/x-www-form-urlencoded", []byte("title=Go+Dev&note=a%0D%0Ab")))
+}
+
+func TestRequestEscapesLineBreaks(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://api.payment.com/", nil)
+	r.RemoteAddr = "127.0.0.1:53124"
+	r.Header["Idempotency-Key"] = []string{"47\n11"}
+	line := format{ProxyFormat: df.ProxyFormat{Headers: []string{"Idempotency-Key"}}}.request(r, nil, time.Date(2024, 4, 8, 10, 50, 59, 0, time.UTC))
+	assert.Equal(t, "2024-04-08T10:50:59Z\t127.0.0.1:53124\tGET http://api.payment.com/ header.idempotency-key=47\\n11\n", line)
+}
+
+func TestTokenize(t *testing.T) {
+	s := "2024-04-08T10:50:59.123Z\t127.0.0.1:53124\tPOST https://api.payment.com/v1/charges/ch_4711?expand=customer&a=1 header.idempotency-key=4711 'body.note=\"it''s\"'\n"
+	matches, pattern := df.MatchesPattern([]string{"POST https://api.payment.com"}, s)
+	assert.True(t, matches)
+	tokens := Tokenizer{}.Tokenize(s, []string{pattern})
+	assert.Equal(t, []string{"POST", "https://api.payment.com", "/v1", "/charges", "/ch_4711", "?a=1", "?expand=customer", "header.idempotency-key=4711", `body.note="it's"`}, tokens)
+	assert.Equal(t, map[string]string{"remote": "127.0.0.1:53124"}, Tokenizer{}.Metadata(s))
+
+	// dynamic values become ignored diffs
+	diff, err := df.Expectation{Tokens: tokens}.Diff(Tokenizer{}.Tokenize(strings.Replace(s, "=4711", "=4712", 1), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{7}, diff)
+}
+
+func TestStatic(t *testing.T) {
+	_, err := LogFactory{}.Static(strings.NewReader("")).NextLine(nil)
+	assert.Error(t, err)
+}
+
+func TestTimestamp(t *testing.T) {
+	actual, err := Log{}.Timestamp("2024-04-08T10:50:59.123Z\t127.0.0.1:53124\tGET https://api.payment.com/")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 8, 10, 50, 59, 123000000, time.UTC), actual)
+}
+
+func nextLine(t *testing.T, l df.Log) string {
+	done := make(chan struct{})
+	timer := time.AfterFunc(5*time.Second, func() { close(done) })
+	defer timer.Stop()
+	line, err := l.NextLine(done)
+	if err != nil || line == "" {
+		t.Fatalf("no request: %v", err)
+	}
+	return line
+}
@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// request returns the request r with body as single line, see Log.
+func (f format) request(r *http.Request, body []byte, t time.Time) string {
+	fields := []string{r.Method, f.url(r).String()}
+	for _, name := range f.Headers {
+		if values := r.Header.Values(name); len(values) > 0 {
+			fields = append(fields, "header."+strings.ToLower(name)+"="+df.LineBreaks.Replace(strings.Join(values, ", ")))
+		}
+	}
+	fields = append(fields, bodyFields(r.Header.Get("Content-Type"), body)...)
+	for i, field := range fields {
+		fields[i] = df.Quote(field)
+	}
+	return t.UTC().Format(time.RFC3339Nano) + "\t" + r.RemoteAddr + "\t" + strings.Join(fields, " ") + "\n"
+}
+
+// url returns the URL of the external service r is forwarded to.
+func (f format) url(r *http.Request) *url.URL {
+	if f.target == nil {
+		u := *r.URL
+		u.User = nil
+		u.Fragment = ""
+		return &u
+	}
+	u := f.target.JoinPath(r.URL.Path)
+	switch {
+	case f.target.RawQuery == "":
+		u.RawQuery = r.URL.RawQuery
+	case r.URL.RawQuery != "":
+		u.RawQuery = f.target.RawQuery + "&" + r.URL.RawQuery
+	}
+	return u
+}
+
+// bodyFields returns the normalized body of a request as fields. Fields of json
+// bodies are sorted by their path and formatted as json, e.g. {"amount": 100,
+// "currency": "EUR"} becomes ["body.amount=100", `body.currency="EUR"`].
+// Parameters of forms are sorted by name, other bodies become a single field.
+// Line breaks of header values, parameters and bodies are escaped.
+func bodyFields(contentType string, body []byte) []string {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if json.Valid(body) && d.Decode(&v) == nil {
+		return df.Flatten("body", v, jsonValue)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		if params, err := url.ParseQuery(string(body)); err == nil {
+			names := make([]string, 0, len(params))
+			for name := range params {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var fields []string
+			for _, name := range names {
+				for _, value := range params[name] {
+					fields = append(fields, "body."+name+"="+df.LineBreaks.Replace(value))
+				}
+			}
+			return fields
+		}
+	}
+	if !utf8.Valid(body) {
+		return []string{fmt.Sprintf("body=<%d bytes>", len(body))}
+	}
+	return []string{"body=" + df.LineBreaks.Replace(string(body))}
+}
+
+// jsonValue formats the json value v, e.g. "EUR" becomes "EUR" including the
+// quotes.
+func jsonValue(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Request is a single request returned by Log.NextLine.
+type Request struct {
+	Time   time.Time
+	Remote string   // address of the SUT
+	Method string   // e.g. POST
+	URL    string   // URL of the external service
+	Fields []string // selected headers and the normalized body, see bodyFields
+}
+
+// ParseRequest parses a request returned by Log.NextLine.
+func ParseRequest(s string) (Request, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return Request{}, errors.New("string contains no request")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Request{}, errors.New("string contains no valid Timestamp")
+	}
+	request := df.Split(fields[2])
+	if len(request) < 2 {
+		return Request{}, errors.New("string contains no request")
+	}
+	return Request{Time: t, Remote: fields[1], Method: request[0], URL: request[1], Fields: request[2:]}, nil
+}
+
+// Tokens returns the method, the scheme and host, the path segments, the query
+// parameters sorted by name and the fields of r as tokens, e.g. ["POST",
+// "https://api.payment.com", "/v1", "/charges", "?expand=customer",
+// "body.amount=100"].
+func (r Request) Tokens() []string {
+	tokens := []string{r.Method}
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		tokens = append(tokens, r.URL)
+		return append(tokens, r.Fields...)
+	}
+	if u.Host != "" {
+		tokens = append(tokens, u.Scheme+"://"+u.Host)
+	}
+	for _, s := range strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/") {
+		tokens = append(tokens, "/"+s)
+	}
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		sort.Strings(params)
+		for _, p := range params {
+			tokens = append(tokens, "?"+p)
+		}
+	}
+	return append(tokens, r.Fields...)
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	log "github.com/sirupsen/logrus"
+)
+
+// server is a running proxy that publishes the requests it forwards to the
+// logs subscribed to it. Servers keep running after their logs were closed, the
+// SUT may call external services between recordings.
+type server struct {
+	format format
+	addr   string // address the proxy listens on
+	proxy  *httputil.ReverseProxy
+
+	mu     sync.Mutex
+	queues map[*queue]bool // queues of the subscribed logs
+}
+
+var (
+	serversMu sync.Mutex
+	servers   = make(map[string]*server) // running servers by their format, see key
+)
+
+// key identifies the proxy of f. Channels with the same proxy settings share
+// their server, different settings with the same listen address fail to
+// listen.
+func key(f df.ProxyFormat) string {
+	return f.Listen + "\t" + f.Target + "\t" + strings.Join(f.Headers, ",")
+}
+
+// serve returns the server of f and starts it if it isn't yet running.
+func serve(f *df.ProxyFormat) (*server, error) {
+	format, err := compile(f)
+	if err != nil {
+		return nil, err
+	}
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	if s, ok := servers[key(format.ProxyFormat)]; ok {
+		return s, nil
+	}
+	listener, err := net.Listen("tcp", format.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	s := &server{format: format, addr: listener.Addr().String(), queues: make(map[*queue]bool)}
+	s.proxy = &httputil.ReverseProxy{Rewrite: func(r *httputil.ProxyRequest) {
+		if format.target != nil {
+			r.SetURL(format.target)
+		}
+	}}
+	servers[key(format.ProxyFormat)] = s
+	go func() {
+		if err := http.Serve(listener, s); err != nil {
+			log.Errorf("proxy %s stopped: %v", s.addr, err)
+		}
+	}()
+	if format.target != nil {
+		log.Printf("proxy listening on %s, forwarding to %s", s.addr, format.Target)
+	} else {
+		log.Printf("forward proxy listening on %s", s.addr)
+	}
+	return s, nil
+}
+
+// ServeHTTP publishes the request r and forwards it to the external service.
+// HTTPS requests can't be recorded by forward proxies, their CONNECT tunnel is
+// rejected.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		http.Error(w, "https requests can't be recorded, configure the proxy target instead", http.StatusNotImplemented)
+		return
+	}
+	if s.format.target == nil && !r.URL.IsAbs() {
+		http.Error(w, "forward proxy requires absolute URLs", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	s.publish(s.format.request(r, body, time.Now()))
+	s.proxy.ServeHTTP(w, r)
+}
+
+func (s *server) subscribe() *queue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := newQueue()
+	s.queues[q] = true
+	return q
+}
+
+func (s *server) unsubscribe(q *queue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.queues, q)
+}
+
+func (s *server) publish(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for q := range s.queues {
+		q.push(line)
+	}
+}
+
+// queue holds the requests published to a log that weren't yet returned by
+// its NextLine. Queues are unbounded, the proxy never waits for its logs.
+type queue struct {
+	mu     sync.Mutex
+	lines  []string
+	signal chan struct{} // signals pushed lines
+}
+
+func newQueue() *queue {
+	return &queue{signal: make(chan struct{}, 1)}
+}
+
+func (q *queue) push(line string) {
+	q.mu.Lock()
+	q.lines = append(q.lines, line)
+	q.mu.Unlock()
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *queue) pop() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.lines) == 0 {
+		return "", false
+	}
+	line := q.lines[0]
+	q.lines = q.lines[1:]
+	return line, true
+}
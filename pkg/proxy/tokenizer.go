@@ -0,0 +1,28 @@
+package proxy
+
+import "github.com/rwirdemann/datafrog/pkg/df"
+
+// Tokenizer tokenizes the requests returned by Log, see Request.Tokens. Each
+// path segment, query parameter, header and body field becomes a single token,
+// thus dynamic values like ids or timestamps are learned as ignored diffs.
+// Patterns match the method and the URL, e.g. "POST https://api.payment.com",
+// but requests aren't cut at the matching pattern.
+type Tokenizer struct {
+}
+
+func (t Tokenizer) Tokenize(s string, _ []string) []string {
+	r, err := ParseRequest(s)
+	if err != nil {
+		return df.Split(s)
+	}
+	return r.Tokens()
+}
+
+// Metadata returns the address of the SUT that sent s.
+func (t Tokenizer) Metadata(s string) map[string]string {
+	r, err := ParseRequest(s)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{"remote": r.Remote}
+}
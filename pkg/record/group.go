@@ -0,0 +1,157 @@
+package record
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Group records a testcase from multiple channels at once. It fans out one
+// Runner per channel and merges the expectations recorded by each runner into a
+// single testcase.
+type Group struct {
+	testname   string
+	testcase   df.Testcase // describes the recorded testcase
+	channels   []df.Channel
+	repository df.TestRepository
+	runners    []*Runner
+
+	mu         sync.Mutex
+	recordings map[string][]df.Expectation // recorded expectations by channel name
+	aborted    bool                        // Start failed, recordings aren't written
+}
+
+// NewGroup creates a new group for recording interactions of the given
+// channels. logs[i] must be the log of channels[i]. The description and
+// channel selection of tc are kept in the recorded testcase.
+func NewGroup(tc df.Testcase, channels []df.Channel, logs []df.Log, repository df.TestRepository) *Group {
+	g := &Group{testname: tc.Name, testcase: tc, channels: channels, repository: repository, recordings: make(map[string][]df.Expectation)}
+	for i, channel := range channels {
+		w := channelWriter{TestRepository: repository, group: g, channel: channel.Name}
+		g.runners = append(g.runners, NewRunner(tc.Name, channel, w, logs[i]))
+	}
+	return g
+}
+
+// Start starts the runners of all channels. If a runner fails to start, the
+// runners started so far are stopped without writing their recording and the
+// logs of all channels are closed.
+func (g *Group) Start() error {
+	for i, r := range g.runners {
+		if err := r.Start(); err != nil {
+			g.abort(i)
+			return err
+		}
+	}
+	return nil
+}
+
+// abort stops the first n runners, that have been started, and closes the logs
+// of the others.
+func (g *Group) abort(n int) {
+	g.mu.Lock()
+	g.aborted = true
+	g.mu.Unlock()
+	for i, r := range g.runners {
+		if i < n {
+			r.Stop()
+		} else {
+			r.channelLog.Close()
+		}
+	}
+}
+
+// Stop stops the runners of all channels and waits till each runner has written
+// its recording.
+func (g *Group) Stop() {
+	for _, r := range g.runners {
+		r.Stop()
+	}
+}
+
+// Err returns the errors that aborted the recording of single channels or nil.
+// The runners of the other channels keep recording.
+func (g *Group) Err() error {
+	var errs []error
+	for _, r := range g.runners {
+		if err := r.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("channel '%s': %w", r.channel.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Switches returns the log file switches of all channels.
+func (g *Group) Switches() []df.LogSwitch {
+	var switches []df.LogSwitch
+	for _, r := range g.runners {
+		switches = append(switches, r.Switches()...)
+	}
+	return switches
+}
+
+// Testcase returns the testcase merged from the recordings of all channels.
+func (g *Group) Testcase() df.Testcase {
+	recordings := make(map[string][]df.Expectation)
+	for _, r := range g.runners {
+		recordings[r.channel.Name] = r.Testcase().Expectations
+	}
+	tc := g.testcase
+	tc.Expectations = merge(g.channels, recordings)
+	if err := g.Err(); err != nil {
+		tc.Error = err.Error()
+	}
+	return tc
+}
+
+// write stores the expectations recorded from channel and writes the merged
+// testcase to the repository.
+func (g *Group) write(channel string, tc df.Testcase) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.aborted {
+		return nil
+	}
+	g.recordings[channel] = tc.Expectations
+	tc.Description = g.testcase.Description
+	tc.Channels = g.testcase.Channels
+	tc.Expectations = merge(g.channels, g.recordings)
+	return g.repository.Write(g.testname, tc)
+}
+
+// merge concatenates the recorded expectations in order of the channels.
+func merge(channels []df.Channel, recordings map[string][]df.Expectation) []df.Expectation {
+	var expectations []df.Expectation
+	var merged []string
+	for _, c := range channels {
+		if contains(merged, c.Name) {
+			continue
+		}
+		merged = append(merged, c.Name)
+		expectations = append(expectations, recordings[c.Name]...)
+	}
+	return expectations
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// channelWriter passes the recording of a single channel to its group instead
+// of writing it directly to the repository.
+type channelWriter struct {
+	df.TestRepository
+	group   *Group
+	channel string
+}
+
+func (w channelWriter) Write(_ string, tc df.Testcase) error {
+	return w.group.write(w.channel, tc)
+}
@@ -0,0 +1,40 @@
+package record
+
+import (
+	"testing"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupMergesRecordingsInChannelOrder(t *testing.T) {
+	channels := []df.Channel{{Name: "mysql"}, {Name: "postgres"}}
+	repository := &mocks.TestRepository{}
+	selection := []df.ChannelSelection{{Name: "mysql"}, {Name: "postgres", Patterns: []string{"insert"}}}
+	g := NewGroup(df.Testcase{Name: "create-job", Description: "creates a job", Channels: selection}, channels, []df.Log{&mocks.SQLLog{}, &mocks.SQLLog{}}, repository)
+
+	assert.NoError(t, g.write("postgres", df.Testcase{Name: "create-job", Expectations: []df.Expectation{{Channel: "postgres", Uuid: "2"}}}))
+	assert.NoError(t, g.write("mysql", df.Testcase{Name: "create-job", Expectations: []df.Expectation{{Channel: "mysql", Uuid: "1"}}}))
+
+	all, _ := repository.All()
+	actual := all[len(all)-1]
+	assert.Len(t, actual.Expectations, 2)
+	assert.Equal(t, "mysql", actual.Expectations[0].Channel)
+	assert.Equal(t, "postgres", actual.Expectations[1].Channel)
+	assert.Equal(t, "creates a job", actual.Description)
+	assert.Equal(t, selection, actual.Channels)
+}
+
+func TestGroupStopsStartedRunnersIfStartFails(t *testing.T) {
+	channels := []df.Channel{{Name: "mysql", Format: "mysql"}, {Name: "oracle", Format: "oracle"}}
+	logs := []*mocks.SQLLog{{}, {}}
+	repository := &mocks.TestRepository{}
+	g := NewGroup(df.Testcase{Name: "create-job"}, channels, []df.Log{logs[0], logs[1]}, repository)
+
+	assert.Error(t, g.Start())
+	<-g.runners[0].stopped
+	assert.True(t, logs[0].Closed)
+	assert.True(t, logs[1].Closed)
+	assert.Empty(t, repository.Testcases)
+}
@@ -0,0 +1,37 @@
+package record
+
+import (
+	"io"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// RecordLog records testname offline from the finished log r of channel, e.g. a
+// database log kept as CI artifact. r may be gzip compressed. Only statements
+// logged between from and to are recorded, zero times leave the period open.
+func RecordLog(tc df.Testcase, channel df.Channel, r io.Reader, from, to time.Time, repository df.TestRepository) (df.Testcase, error) {
+	format, err := df.LookupFormat(channel.Format)
+	if err != nil {
+		return df.Testcase{}, err
+	}
+	tokenizer, err := df.NewTokenizer(format.Tokenizer, channel)
+	if err != nil {
+		return df.Testcase{}, err
+	}
+
+	content, err := df.Decompress(r)
+	if err != nil {
+		return df.Testcase{}, err
+	}
+	channelLog := format.LogFactory(channel).Static(content)
+	defer channelLog.Close()
+
+	// the group keeps description and channel selection of tc
+	g := NewGroup(tc, []df.Channel{channel}, []df.Log{channelLog}, repository)
+	w := channelWriter{TestRepository: repository, group: g, channel: channel.Name}
+	recorder := NewRecorder(channel, tokenizer, channelLog, df.FixedTimer{From: from, To: to}, tc.Name, df.GoogleUUIDProvider{}, w)
+	err = recorder.Run()
+	tc.Expectations = recorder.Testcase().Expectations
+	return tc, err
+}
@@ -0,0 +1,53 @@
+package record
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordLog(t *testing.T) {
+	logs := "2024-04-08T12:50:58.000000Z	 2609 Query	insert into job (description, id) values ('World', 2)\n" +
+		"2024-04-08T12:50:59.605638Z	 2609 Query	insert into job (description, id) values ('World', 3)\n" +
+		"2024-04-08T12:51:00.000000Z	 2609 Query	update job set description='World' where id=3\n" +
+		"2024-04-08T12:51:01.000000Z	 2609 Query	insert into job (description, id) values ('World', 4)"
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, _ = w.Write([]byte(logs))
+	_ = w.Close()
+
+	from, _ := time.Parse(time.RFC3339, "2024-04-08T12:50:59Z")
+	to, _ := time.Parse(time.RFC3339, "2024-04-08T12:51:00Z")
+	channel := df.Channel{Name: "mysql", Format: "mysql", Patterns: []string{"insert", "update"}}
+	repository := &mocks.TestRepository{}
+	tc, err := RecordLog(df.Testcase{Name: "create-job", Description: "from CI"}, channel, &compressed, from, to, repository)
+	assert.NoError(t, err)
+	assert.Len(t, tc.Expectations, 2)
+	assert.Equal(t, "insert", tc.Expectations[0].Tokens[0])
+	assert.Equal(t, "update", tc.Expectations[1].Tokens[0])
+
+	actual, err := repository.Get("create-job")
+	assert.NoError(t, err)
+	assert.Equal(t, "from CI", actual.Description)
+	assert.Len(t, actual.Expectations, 2)
+}
+
+func TestRecordSlowLogKeepsMetadata(t *testing.T) {
+	logs := "# Time: 2024-04-08T12:50:59.605638Z\n" +
+		"# User@Host: root[root] @ localhost []  Id:  2609\n" +
+		"# Query_time: 0.000123  Lock_time: 0.000001 Rows_sent: 0  Rows_examined: 3\n" +
+		"SET timestamp=1712580659;\n" +
+		"insert into job (description, id) values ('World', 3);\n"
+	channel := df.Channel{Name: "mysql", Format: "mysql-slow", Patterns: []string{"insert"}}
+	tc, err := RecordLog(df.Testcase{Name: "create-job"}, channel, strings.NewReader(logs), time.Time{}, time.Time{}, &mocks.TestRepository{})
+	assert.NoError(t, err)
+	assert.Len(t, tc.Expectations, 1)
+	assert.Equal(t, "0.000123", tc.Expectations[0].Metadata["Query_time"])
+	assert.Equal(t, "3", tc.Expectations[0].Metadata["Rows_examined"])
+}
@@ -1,6 +1,10 @@
 package record
 
 import (
+	"errors"
+	"io"
+	"sync"
+
 	"github.com/rwirdemann/datafrog/pkg/df"
 	log "github.com/sirupsen/logrus"
 )
@@ -17,6 +21,8 @@ type Recorder struct {
 	uuidProvider   UUIDProvider
 	testcase       df.Testcase
 	testRepository df.TestRepository
+	err            error      // error that aborted the recording
+	mu             sync.Mutex // guards testcase and err, that are read while recording
 }
 
 // NewRecorder creates a new Recorder.
@@ -39,7 +45,10 @@ func NewRecorder(channel df.Channel, tokenizer df.Tokenizer,
 // Start starts the recording process of channel as endless loop. Every log entry
 // that matches one of the patterns specified in the channels pattern list is
 // written to the recording sink. Only log entries that fall in the actual
-// recording period are considered.
+// recording period are considered. After done was closed, the log is drained up
+// to the stop time of the timer, see [df.Drain]. If reading the log fails the recording
+// stops and the error is kept, see Err. The expectations recorded so far are
+// written anyway.
 func (r *Recorder) Start(done chan struct{}, stopped chan struct{}) {
 	r.timer.Start()
 	log.Printf("Recording started at %v...", r.timer.GetStart())
@@ -49,14 +58,15 @@ func (r *Recorder) Start(done chan struct{}, stopped chan struct{}) {
 
 	// called when done channel is closed
 	defer func() {
-		if err := r.testRepository.Write(r.testname, r.testcase); err != nil {
-			log.Fatal(err)
+		if err := r.testRepository.Write(r.testname, r.Testcase()); err != nil {
+			r.fail(err)
 		}
 	}()
 
 	// jump to log file end
 	if err := r.log.Tail(); err != nil {
-		log.Fatal(err)
+		r.fail(err)
+		return
 	}
 
 	for {
@@ -64,30 +74,92 @@ func (r *Recorder) Start(done chan struct{}, stopped chan struct{}) {
 		default:
 			line, err := r.log.NextLine(done)
 			if err != nil {
-				log.Fatal(err)
-			}
-			ts, err := r.log.Timestamp(line)
-			if err != nil {
-				continue
-			}
-			if r.timer.MatchesRecordingPeriod(ts) {
-				matches, pattern := df.MatchesPattern(r.channel.Patterns, line)
-				if matches {
-					tokens := r.tokenizer.Tokenize(line, r.channel.Patterns)
-					e := df.Expectation{Uuid: r.uuidProvider.NewString(), Tokens: tokens, IgnoreDiffs: []int{}, Pattern: pattern}
-					r.testcase.Expectations = append(r.testcase.Expectations, e)
-					log.Printf("new expectation: %s\n", e.Shorten(8))
-				}
+				r.fail(err)
+				return
 			}
+			r.record(line)
 		// check if the caller (web, cli, ...) has closed the done channel to
 		// tell me that recoding has been finished
 		case <-done:
 			log.Println("recorder: done channel closed")
+
+			// record statements that were flushed to the log after the stop
+			if err := df.Drain(r.log, r.timer, r.channel.Grace(), r.record); err != nil {
+				r.fail(err)
+			}
 			return
 		}
 	}
 }
 
+// Run records all statements of a finished log, see [df.LogFactory.Static].
+// Unlike Start, Run reads the log from its current position instead of tailing
+// it and returns at its end. The recording is written even if reading the log
+// fails.
+func (r *Recorder) Run() error {
+	r.timer.Start()
+	log.Printf("Recording %s between %v and %v...", r.testname, r.timer.GetStart(), r.timer.GetStop())
+	for {
+		line, err := r.log.NextLine(nil)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			r.fail(err)
+			break
+		}
+		r.record(line)
+	}
+	if err := r.testRepository.Write(r.testname, r.Testcase()); err != nil {
+		r.fail(err)
+	}
+	return r.Err()
+}
+
+// record adds line as new expectation if it falls in the recording period and
+// matches one of the channels patterns.
+func (r *Recorder) record(line string) {
+	ts, err := r.log.Timestamp(line)
+	if err != nil {
+		return
+	}
+	if !df.InRecordingPeriod(r.timer, r.log, ts) {
+		return
+	}
+	matches, pattern := df.MatchesPattern(r.channel.Patterns, df.Statement(r.tokenizer, line))
+	if matches {
+		tokens := r.tokenizer.Tokenize(line, r.channel.Patterns)
+		e := df.Expectation{Uuid: r.uuidProvider.NewString(), Channel: r.channel.Name, Tokens: tokens, IgnoreDiffs: []int{}, Pattern: pattern,
+			Metadata: df.Metadata(r.tokenizer, line), Original: df.Original(r.tokenizer, line, r.channel.Patterns)}
+		r.mu.Lock()
+		r.testcase.Expectations = append(r.testcase.Expectations, e)
+		r.mu.Unlock()
+		log.Printf("new expectation: %s\n", e.Shorten(8))
+	}
+}
+
+// fail keeps the first error that aborted the recording.
+func (r *Recorder) fail(err error) {
+	log.Errorf("recorder: %v", err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the error that aborted the recording or nil.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Testcase returns a copy of the testcase recorded so far.
 func (r *Recorder) Testcase() df.Testcase {
-	return r.testcase
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tc := r.testcase
+	tc.Expectations = append([]df.Expectation(nil), r.testcase.Expectations...)
+	return tc
 }
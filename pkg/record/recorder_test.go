@@ -1,10 +1,12 @@
 package record
 
 import (
+	"errors"
 	"github.com/rwirdemann/datafrog/pkg/df"
 	"github.com/rwirdemann/datafrog/pkg/mocks"
 	"github.com/rwirdemann/datafrog/pkg/mysql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -60,3 +62,50 @@ func TestRecord(t *testing.T) {
 	assert.Len(t, actual.Expectations, 2)
 	assert.Equal(t, expectedTestcase, actual)
 }
+
+func TestRecordStopsOnLogError(t *testing.T) {
+	logs := []string{
+		"2024-04-08T12:50:59.605638Z	 2609 Query	insert into job (description, id) values ('World', 3)",
+	}
+	logErr := errors.New("log file vanished")
+	channel := df.Channel{Patterns: []string{"insert"}}
+	recordingDone := make(chan struct{})
+	recordingStopped := make(chan struct{})
+	repository := &mocks.TestRepository{}
+	recorder := NewRecorder(channel, mysql.Tokenizer{}, mocks.NewFailingSQLLog(logs, logErr), mocks.Timer{}, "create-job", mocks.StaticUUIDProvider{}, repository)
+	go recorder.Start(recordingDone, recordingStopped)
+	<-recordingStopped
+	assert.ErrorIs(t, recorder.Err(), logErr)
+
+	// the expectations recorded so far are written anyway
+	actual, err := repository.Get("create-job")
+	assert.NoError(t, err)
+	assert.Len(t, actual.Expectations, 1)
+}
+
+func TestRecordDrainsLogUpToStopTime(t *testing.T) {
+	logs := []string{
+		"STOP",
+
+		// flushed late, but executed before the stop
+		"2024-04-08T12:50:59.605638Z	 2609 Query	insert into job (description, id) values ('World', 3)",
+
+		// executed after the stop
+		"2024-04-08T12:51:00.000001Z	 2609 Query	insert into job (description, id) values ('World', 4)",
+		"2024-04-08T12:51:01.000000Z	 2609 Query	insert into job (description, id) values ('World', 5)",
+	}
+	stop, _ := time.Parse(time.RFC3339Nano, "2024-04-08T12:51:00Z")
+	channel := df.Channel{Patterns: []string{"insert"}}
+	recordingDone := make(chan struct{})
+	recordingStopped := make(chan struct{})
+	databaseLog := mocks.NewMemSQLLog(logs, recordingDone)
+	repository := &mocks.TestRepository{}
+	recorder := NewRecorder(channel, mysql.Tokenizer{}, databaseLog, mocks.Timer{End: stop}, "create-job", mocks.StaticUUIDProvider{}, repository)
+	go recorder.Start(recordingDone, recordingStopped)
+	<-recordingStopped
+
+	actual, err := repository.Get("create-job")
+	assert.NoError(t, err)
+	assert.Len(t, actual.Expectations, 1)
+	assert.Equal(t, "3)", actual.Expectations[0].Tokens[len(actual.Expectations[0].Tokens)-1])
+}
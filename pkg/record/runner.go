@@ -2,8 +2,6 @@ package record
 
 import (
 	"github.com/rwirdemann/datafrog/pkg/df"
-	"github.com/rwirdemann/datafrog/pkg/mysql"
-	"github.com/rwirdemann/datafrog/pkg/postgres"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -14,6 +12,7 @@ type Runner struct {
 	repository df.TestRepository
 	channelLog df.Log
 	recorder   *Recorder
+	timer      df.Timer
 	done       chan struct{}
 	stopped    chan struct{}
 }
@@ -26,14 +25,16 @@ func NewRunner(testname string, channel df.Channel, repository df.TestRepository
 
 // Start starts a new recorder as go routine.
 func (r *Runner) Start() error {
-	var tokenizer df.Tokenizer
-	if r.channel.Format == "mysql" {
-		tokenizer = mysql.Tokenizer{}
+	format, err := df.LookupFormat(r.channel.Format)
+	if err != nil {
+		return err
 	}
-	if r.channel.Format == "postgres" {
-		tokenizer = postgres.Tokenizer{}
+	tokenizer, err := df.NewTokenizer(format.Tokenizer, r.channel)
+	if err != nil {
+		return err
 	}
-	r.recorder = NewRecorder(r.channel, tokenizer, r.channelLog, &df.UTCTimer{}, r.testname, df.GoogleUUIDProvider{}, r.repository)
+	r.timer = &df.UTCTimer{}
+	r.recorder = NewRecorder(r.channel, tokenizer, r.channelLog, r.timer, r.testname, df.GoogleUUIDProvider{}, r.repository)
 	r.done = make(chan struct{})
 	r.stopped = make(chan struct{})
 	go r.recorder.Start(r.done, r.stopped)
@@ -41,10 +42,12 @@ func (r *Runner) Start() error {
 }
 
 // Stop stops the recording by closing the done channel, that is checked by the
-// recorder for its termination. Closes also the channels log file and test
-// writer.
+// recorder for its termination. The recording period ends now, but the recorder
+// keeps reading statements that are flushed late to the log. Closes also the
+// channels log file and test writer.
 func (r *Runner) Stop() {
 	// tell recorder that recording has been finished
+	r.timer.Stop()
 	close(r.done)
 	log.Printf("rrunner: waiting for stopped channel to be closed")
 
@@ -56,7 +59,34 @@ func (r *Runner) Stop() {
 	r.channelLog.Close()
 }
 
-// Testcase returns the testcase.
+// Testcase returns the testcase. The testcase is empty if the runner wasn't
+// started.
 func (r *Runner) Testcase() df.Testcase {
-	return r.recorder.testcase
+	if r.recorder == nil {
+		return df.Testcase{}
+	}
+	return r.recorder.Testcase()
+}
+
+// Err returns the error that aborted the recording or nil.
+func (r *Runner) Err() error {
+	if r.recorder == nil {
+		return nil
+	}
+	return r.recorder.Err()
+}
+
+// Switches returns the rotations and truncations of the channel's log file
+// tagged with the channel.
+func (r *Runner) Switches() []df.LogSwitch {
+	f, ok := r.channelLog.(df.Follower)
+	if !ok {
+		return nil
+	}
+	var switches []df.LogSwitch
+	for _, s := range f.Switches() {
+		s.Channel = r.channel.Name
+		switches = append(switches, s)
+	}
+	return switches
 }
@@ -0,0 +1,46 @@
+package record
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// clockLog returns an insert statement logged at the current time every
+// millisecond.
+type clockLog struct{}
+
+func (l clockLog) Tail() error { return nil }
+func (l clockLog) Close()      {}
+
+func (l clockLog) Timestamp(s string) (time.Time, error) {
+	ts, _, _ := strings.Cut(s, "\t")
+	return time.Parse(time.RFC3339Nano, ts)
+}
+
+func (l clockLog) NextLine(done chan struct{}) (string, error) {
+	select {
+	case <-done:
+		return "", nil
+	case <-time.After(time.Millisecond):
+		return time.Now().UTC().Format(time.RFC3339Nano) + "\t 2609 Query\tinsert into job (id) values (1)\n", nil
+	}
+}
+
+// TestRunnerStartStop runs a runner with its UTCTimer, run it with -race to
+// detect unsynchronized access to the timer.
+func TestRunnerStartStop(t *testing.T) {
+	channel := df.Channel{Name: "mysql", Format: "mysql", Patterns: []string{"insert"}}
+	repository := &mocks.TestRepository{}
+	r := NewRunner("create-job", channel, repository, clockLog{})
+	assert.NoError(t, r.Start())
+	time.Sleep(20 * time.Millisecond)
+	r.Stop()
+	assert.NoError(t, r.Err())
+	assert.NotEmpty(t, r.Testcase().Expectations)
+	assert.Len(t, repository.Testcases, 1)
+}
@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// monitorRegex matches the lines written by redis-cli MONITOR:
+//
+//	1712040000.123456 [0 127.0.0.1:5555] "SET" "job:5" "Java Dev"
+//
+// Submatches are the seconds and microseconds of the epoch timestamp, the
+// database, the client address and the quoted arguments. Commands of Lua
+// scripts are logged with the client "lua".
+var monitorRegex = regexp.MustCompile(`^(\d+)\.(\d{1,9}) \[(\d+) ([^\]]+)\] (".*)$`)
+
+// parseMonitorLine returns the command of line as single line, see Log. Returns
+// false if line contains no command, e.g. the OK redis-cli prints at start.
+func parseMonitorLine(line string) (string, bool) {
+	m := monitorRegex.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return "", false
+	}
+	seconds, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	nanos, _ := strconv.ParseInt((m[2] + "000000000")[:9], 10, 64)
+	args, ok := unquote(m[5])
+	if !ok || len(args) == 0 {
+		return "", false
+	}
+	for i, a := range args {
+		args[i] = df.Quote(a)
+	}
+	t := time.Unix(seconds, nanos).UTC()
+	return t.Format(time.RFC3339Nano) + "\t" + m[3] + " " + m[4] + "\t" + strings.Join(args, " ") + "\n", true
+}
+
+// unquote splits the double quoted arguments s of a MONITOR line. Escaped
+// quotes and backslashes are unescaped, other escapes like \n or \x00 are kept
+// thus arguments remain on a single line.
+func unquote(s string) ([]string, bool) {
+	var args []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] != '"' {
+			return nil, false
+		}
+		var b strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				if s[i] != '"' && s[i] != '\\' {
+					b.WriteByte('\\')
+				}
+			}
+			b.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return nil, false
+		}
+		args = append(args, b.String())
+		s = s[i+1:]
+	}
+	return args, true
+}
+
+// Command is a single command returned by Log.NextLine.
+type Command struct {
+	Time      time.Time
+	DB        string // number of the selected database
+	Client    string // address of the client, e.g. 127.0.0.1:5555
+	Statement string // command and its arguments, see df.Split
+}
+
+// ParseCommand parses a command returned by Log.NextLine.
+func ParseCommand(s string) (Command, error) {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return Command{}, errors.New("string contains no command")
+	}
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Command{}, errors.New("string contains no valid Timestamp")
+	}
+	db, client, _ := strings.Cut(fields[1], " ")
+	return Command{Time: t, DB: db, Client: client, Statement: fields[2]}, nil
+}
@@ -0,0 +1,53 @@
+// Package redis implements the redis format, which reads commands captured by
+// redis-cli:
+//
+//	redis-cli MONITOR > monitor.log
+package redis
+
+import (
+	"io"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Log reads the commands captured by redis-cli MONITOR. Each command is
+// returned as single line that contains the time in UTC, the database, the
+// client address and the command with its arguments:
+//
+//	2024-04-02T06:40:00.123456Z	0 127.0.0.1:5555	SET job:5 'Java Dev'
+type Log struct {
+	df.LineReader
+}
+
+// NewStaticLog creates a log that reads the finished log r. Instead of waiting
+// for new lines NextLine returns io.EOF at the end of r.
+func NewStaticLog(r io.Reader) Log {
+	return Log{LineReader: df.NewStaticLineReader(r)}
+}
+
+// NewRedisLog opens the log logfileName. The log is followed across rotation
+// and truncation according to the follow mode.
+func NewRedisLog(logfileName string, follow string) (Log, error) {
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader}, nil
+}
+
+// Timestamp returns the time of a command returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	c, err := ParseCommand(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.Time, nil
+}
+
+// NextLine returns the next command. Waits until a new command becomes
+// available or done is closed, static logs return io.EOF at their end instead.
+// Returns with an empty line and a nil error if the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	return m.LineReader.NextLine(done, df.Parser(parseMonitorLine))
+}
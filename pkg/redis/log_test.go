@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextLine(t *testing.T) {
+	l := NewStaticLog(strings.NewReader("OK\n" +
+		`1712040000.123456 [0 127.0.0.1:5555] "SET" "job:5" "Java Dev" "EX" "60"` + "\n" +
+		`1712040000.2 [1 lua] "HSET" "job:5:meta" "title" "O'Reilly \"Dev\"" "empty" ""` + "\n" +
+		`1712040001.000001 [0 unix:/tmp/redis.sock] "SET" "blob" "a\nb\\c\x00"`))
+	assert.Equal(t, "2024-04-02T06:40:00.123456Z\t0 127.0.0.1:5555\tSET job:5 'Java Dev' EX 60\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-02T06:40:00.2Z\t1 lua\tHSET job:5:meta title 'O''Reilly \"Dev\"' empty ''\n", nextLine(t, l))
+	assert.Equal(t, "2024-04-02T06:40:01.000001Z\t0 unix:/tmp/redis.sock\tSET blob a\\nb\\c\\x00\n", nextLine(t, l))
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTokenize(t *testing.T) {
+	s := "2024-04-02T06:40:00.2Z\t1 lua\tHSET job:5:meta title 'O''Reilly \"Dev\"' empty ''\n"
+	assert.Equal(t, []string{"HSET", "job:5:meta", "title", `O'Reilly "Dev"`, "empty", ""}, Tokenizer{}.Tokenize(s, []string{"job:"}))
+	assert.Equal(t, map[string]string{"db": "1", "client": "lua"}, Tokenizer{}.Metadata(s))
+
+	// keys with generated ids become ignored diffs
+	tokens := Tokenizer{}.Tokenize("2024-04-02T06:40:00Z\t0 127.0.0.1:5555\tSET job:5 'Java Dev'", nil)
+	diff, err := df.Expectation{Tokens: tokens}.Diff(Tokenizer{}.Tokenize("2024-04-02T06:41:00Z\t0 127.0.0.1:5556\tSET job:6 'Java Dev'", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, diff)
+}
+
+func TestTimestamp(t *testing.T) {
+	actual, err := Log{}.Timestamp("2024-04-02T06:40:00.123456Z\t0 127.0.0.1:5555\tGET job:5")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 2, 6, 40, 0, 123456000, time.UTC), actual)
+}
+
+func TestSkipsInvalidMonitorLines(t *testing.T) {
+	l := NewStaticLog(strings.NewReader(`1712040000.1 [0 127.0.0.1:5555] "SET" "job:5` + "\n" +
+		`1712040000.2 [0 127.0.0.1:5555] SET job:5` + "\n" +
+		`1712040000.3 [0 127.0.0.1:5555] ` + "\n" +
+		`1712040000.123456789 [0 127.0.0.1:5555] "GET" "job:5"` + "\r\n"))
+	line := nextLine(t, l)
+	assert.Equal(t, "2024-04-02T06:40:00.123456789Z\t0 127.0.0.1:5555\tGET job:5\n", line)
+	_, err := l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+
+	actual, err := l.Timestamp(line)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 2, 6, 40, 0, 123456789, time.UTC), actual)
+}
+
+func nextLine(t *testing.T, l Log) string {
+	line, err := l.NextLine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
@@ -0,0 +1,27 @@
+package redis
+
+import "github.com/rwirdemann/datafrog/pkg/df"
+
+// Tokenizer tokenizes the commands returned by Log. Each argument becomes a
+// single token, e.g. ["SET", "job:5", "Java Dev"]. Thus keys and values that
+// differ between runs, like keys with generated ids, are learned as ignored
+// diffs. Like the mongodb format commands aren't cut at the matching pattern,
+// patterns may match keys and the command is kept anyway.
+type Tokenizer struct {
+}
+
+func (t Tokenizer) Tokenize(s string, _ []string) []string {
+	if c, err := ParseCommand(s); err == nil {
+		s = c.Statement
+	}
+	return df.Split(s)
+}
+
+// Metadata returns the database and the client address of s.
+func (t Tokenizer) Metadata(s string) map[string]string {
+	c, err := ParseCommand(s)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{"db": c.DB, "client": c.Client}
+}
@@ -0,0 +1,159 @@
+// Package regex implements the regex log format, which is configured by a
+// df.RegexFormat instead of code. It allows to record and verify logs of
+// databases and applications datafrog has no dedicated format for, e.g.
+// SQLite traces or H2 trace files.
+package regex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	log "github.com/sirupsen/logrus"
+)
+
+// entryTimeout is the time to wait for further continuation lines of the last
+// entry when the end of the log was reached.
+const entryTimeout = time.Second
+
+func init() {
+	df.RegisterFormat(df.Format{
+		Name: "regex",
+		LogFactory: func(channel df.Channel) df.LogFactory {
+			return LogFactory{Format: channel.Regex, Follow: channel.Follow}
+		},
+		Tokenizer: Tokenizer{},
+		Validate: func(channel df.Channel) error {
+			_, err := compile(channel.Regex)
+			return err
+		},
+	})
+}
+
+// format is the compiled df.RegexFormat.
+type format struct {
+	line         *regexp.Regexp
+	layout       string
+	location     *time.Location
+	continuation *regexp.Regexp // nil: lines that don't match line
+	precision    time.Duration  // resolution of the timestamps, 0 if fractional
+}
+
+func compile(f *df.RegexFormat) (format, error) {
+	if f == nil {
+		return format{}, errors.New("regex format requires the channel setting regex")
+	}
+	line, err := regexp.Compile(f.Line)
+	if err != nil {
+		return format{}, fmt.Errorf("line: %w", err)
+	}
+	for _, group := range []string{"timestamp", "statement"} {
+		if line.SubexpIndex(group) < 0 {
+			return format{}, fmt.Errorf("line requires the named group '%s'", group)
+		}
+	}
+	if f.TimestampLayout == "" {
+		return format{}, errors.New("timestamp_layout is required")
+	}
+	location := time.Local
+	if f.Timezone != "" {
+		if location, err = time.LoadLocation(f.Timezone); err != nil {
+			return format{}, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	var continuation *regexp.Regexp
+	if f.Continuation != "" {
+		if continuation, err = regexp.Compile(f.Continuation); err != nil {
+			return format{}, fmt.Errorf("continuation: %w", err)
+		}
+	}
+	return format{line: line, layout: f.TimestampLayout, location: location, continuation: continuation,
+		precision: df.LayoutPrecision(f.TimestampLayout)}, nil
+}
+
+// entry is a log entry assembled from its first line and its continuation
+// lines.
+type entry struct {
+	format    format
+	timestamp time.Time
+	session   string
+	statement string
+	started   bool
+	read      time.Time // time the last line was read
+}
+
+// Add adds the physical line read from the log. Returns the previous entry if
+// line starts a new entry. Lines that are neither the first line of an entry
+// nor a continuation line are dropped, as are entries whose timestamp doesn't
+// match the timestamp layout.
+func (e *entry) Add(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", false
+	}
+	e.read = time.Now()
+	if e.format.continuation != nil && e.format.continuation.MatchString(line) {
+		e.join(line)
+		return "", false
+	}
+	m := e.format.line.FindStringSubmatch(line)
+	if m == nil {
+		if e.format.continuation == nil {
+			e.join(line)
+		}
+		return "", false
+	}
+	previous := e.flush()
+	t, err := time.ParseInLocation(e.format.layout, m[e.format.line.SubexpIndex("timestamp")], e.format.location)
+	if err != nil {
+		log.Errorf("skipping entry with invalid timestamp: %v", err)
+		return previous, previous != ""
+	}
+	e.timestamp = t
+	e.session = ""
+	if i := e.format.line.SubexpIndex("session"); i >= 0 {
+		e.session = m[i]
+	}
+	e.statement = m[e.format.line.SubexpIndex("statement")]
+	e.started = true
+	return previous, previous != ""
+}
+
+func (e *entry) join(line string) {
+	if e.started {
+		e.statement = e.statement + " " + strings.TrimSpace(line)
+	}
+}
+
+// Flush returns the current entry at the end of a static log or if no further
+// lines were written for entryTimeout. Otherwise the entry is completed by the
+// first line of the next entry.
+func (e *entry) Flush(eof bool, end bool) (string, bool) {
+	if !end && !(eof && time.Since(e.read) > entryTimeout) {
+		return "", false
+	}
+	s := e.flush()
+	return s, s != ""
+}
+
+// flush returns the current entry and resets it. Returns an empty string if
+// there is no entry.
+func (e *entry) flush() string {
+	if !e.started {
+		return ""
+	}
+	e.started = false
+	return e.timestamp.UTC().Format(time.RFC3339Nano) + "\t" + e.session + "\t" + e.statement + "\n"
+}
+
+// statement returns the statement of a line returned by Log.NextLine.
+func statement(s string) string {
+	fields := strings.SplitN(strings.TrimRight(s, "\r\n"), "\t", 3)
+	if len(fields) != 3 {
+		return s
+	}
+	return fields[2]
+}
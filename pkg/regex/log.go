@@ -0,0 +1,73 @@
+package regex
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Log reads log files of the regex format. Entries are returned as single line
+// that contains the timestamp in UTC, the session and the statement:
+//
+//	2024-04-08T09:39:15.07Z	2549	select * from job where id=5
+type Log struct {
+	df.LineReader
+	current *entry // entry assembled from the lines read so far
+	err     error  // invalid format of a static log
+}
+
+// NewStaticLog creates a log of format f that reads the finished log r.
+func NewStaticLog(r io.Reader, f *df.RegexFormat) (Log, error) {
+	format, err := compile(f)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: df.NewStaticLineReader(r), current: &entry{format: format}}, nil
+}
+
+// NewRegexLog opens the log logfileName of format f. The log is followed across
+// rotation and truncation according to the follow mode.
+func NewRegexLog(logfileName string, f *df.RegexFormat, follow string) (Log, error) {
+	format, err := compile(f)
+	if err != nil {
+		return Log{}, err
+	}
+	reader, err := df.NewLineReader(logfileName, follow, nil)
+	if err != nil {
+		return Log{}, err
+	}
+	return Log{LineReader: reader, current: &entry{format: format}}, nil
+}
+
+// Timestamp returns the timestamp of a line returned by NextLine.
+func (m Log) Timestamp(s string) (time.Time, error) {
+	ts, _, _ := strings.Cut(s, "\t")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, errors.New("string contains no valid Timestamp")
+	}
+	return t, nil
+}
+
+// Precision returns the resolution of the timestamps, that is a second if the
+// timestamp layout has no fractional seconds.
+func (m Log) Precision() time.Duration {
+	if m.current == nil {
+		return 0
+	}
+	return m.current.format.precision
+}
+
+// NextLine returns the next entry of the log file joined with its continuation
+// lines. Waits until a new entry becomes available or done is closed, static
+// logs return io.EOF at their end instead. Returns with an empty line and a nil
+// error if the done channel was closed.
+func (m Log) NextLine(done chan struct{}) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.LineReader.NextLine(done, m.current)
+}
@@ -0,0 +1,30 @@
+package regex
+
+import (
+	"io"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type LogFactory struct {
+	Format *df.RegexFormat // layout of created logs
+	Follow string          // follow mode of created logs, see df.FollowNotify
+}
+
+func (f LogFactory) Create(filename string) (df.Log, error) {
+	l, err := NewRegexLog(filename, f.Format, f.Follow)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Static creates a static log. If the format is invalid NextLine returns the
+// error.
+func (f LogFactory) Static(r io.Reader) df.Log {
+	l, err := NewStaticLog(r, f.Format)
+	if err != nil {
+		return Log{err: err}
+	}
+	return l
+}
@@ -0,0 +1,125 @@
+package regex
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var h2 = &df.RegexFormat{
+	Line:            `^(?P<timestamp>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) jdbc\[(?P<session>\d+)\]: (?P<statement>.*)$`,
+	TimestampLayout: "2006-01-02 15:04:05",
+	Timezone:        "Europe/Berlin",
+	Continuation:    `^\s`,
+}
+
+func TestNextLine(t *testing.T) {
+	l, err := NewStaticLog(strings.NewReader("2024-04-08 11:39:15 jdbc[3]: select *\n"+
+		"  from job where id=5\n"+
+		"unrelated line\n"+
+		"2024-04-08 11:39:16 jdbc[4]: insert into job (title) values ('Hello')"), h2)
+	assert.Nil(t, err)
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15Z\t3\tselect * from job where id=5\n", line)
+	line, err = l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:16Z\t4\tinsert into job (title) values ('Hello')\n", line)
+	_, err = l.NextLine(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestUnmatchedLinesContinueWithoutContinuationRule(t *testing.T) {
+	format := &df.RegexFormat{Line: `^(?P<timestamp>\d+) (?P<statement>.*)$`, TimestampLayout: "20060102150405", Timezone: "UTC"}
+	l, err := NewStaticLog(strings.NewReader("20240408093915 select *\nfrom job\n"), format)
+	assert.Nil(t, err)
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:15Z\t\tselect * from job\n", line)
+}
+
+func TestSkipsEntryWithInvalidTimestamp(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	l, err := NewStaticLog(strings.NewReader("2024-04-08 25:39:15 jdbc[3]: select 1\n"+
+		"  from dual\n"+
+		"2024-04-08 11:39:16 jdbc[4]: select 2\n"), h2)
+	assert.Nil(t, err)
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-04-08T09:39:16Z\t4\tselect 2\n", line)
+	assert.Contains(t, hook.LastEntry().Message, "invalid timestamp")
+}
+
+func TestWaitsForContinuationOfLastEntry(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "h2.trace.db")
+	if err := os.WriteFile(name, []byte("2024-04-08 11:39:15 jdbc[3]: select *\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewRegexLog(name, h2, df.FollowPoll)
+	assert.Nil(t, err)
+	defer l.Close()
+
+	lines := make(chan string)
+	go func() {
+		line, _ := l.NextLine(make(chan struct{}))
+		lines <- line
+	}()
+	time.Sleep(entryTimeout / 2)
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteString("  from job where id=5\n")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	assert.Equal(t, "2024-04-08T09:39:15Z\t3\tselect * from job where id=5\n", <-lines)
+}
+
+func TestTimestamp(t *testing.T) {
+	actual, err := Log{}.Timestamp("2024-04-08T09:39:15.07Z\t3\tselect 1\n")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 4, 8, 9, 39, 15, 70000000, time.UTC), actual)
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenizer{}.Tokenize("2024-04-08T09:39:15Z\t3\tselect * from job where id=5\n", []string{"select"})
+	assert.Equal(t, []string{"select", "*", "from", "job", "where", "id=5"}, tokens)
+}
+
+func TestPrecision(t *testing.T) {
+	l, err := NewStaticLog(strings.NewReader("2024-04-08 11:39:15 jdbc[3]: select 1\n"), h2)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Second, l.Precision())
+	line, err := l.NextLine(nil)
+	assert.Nil(t, err)
+	ts, err := l.Timestamp(line)
+	assert.Nil(t, err)
+	start := time.Date(2024, 4, 8, 9, 39, 15, 500000000, time.UTC)
+	assert.True(t, df.InRecordingPeriod(df.FixedTimer{From: start}, l, ts))
+	assert.False(t, df.InRecordingPeriod(df.FixedTimer{From: start.Add(time.Second)}, l, ts))
+
+	l, err = NewStaticLog(strings.NewReader(""), &df.RegexFormat{Line: h2.Line, TimestampLayout: "2006-01-02 15:04:05.000"})
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), l.Precision())
+}
+
+func TestStatement(t *testing.T) {
+	s := "2024-04-08T09:39:15Z\tupdate_job\tselect * from job where id=5\n"
+	assert.Equal(t, "select * from job where id=5", Tokenizer{}.Statement(s))
+	matches, _ := df.MatchesPattern([]string{"update"}, df.Statement(Tokenizer{}, s))
+	assert.False(t, matches)
+}
+
+func TestInvalidFormat(t *testing.T) {
+	_, err := NewStaticLog(strings.NewReader(""), &df.RegexFormat{Line: `^(?P<statement>.*)$`, TimestampLayout: time.DateTime})
+	assert.NotNil(t, err)
+	_, err = LogFactory{}.Static(strings.NewReader("")).NextLine(nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,20 @@
+package regex
+
+import (
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+type Tokenizer struct {
+}
+
+// Tokenize cuts timestamp and session from s. The statement is split by spaces
+// into single tokens afterward.
+func (t Tokenizer) Tokenize(s string, patterns []string) []string {
+	return df.Tokenize(df.CutPrefix(statement(s), patterns))
+}
+
+// Statement returns the statement of s without timestamp and session, patterns
+// are matched against the statement only.
+func (t Tokenizer) Statement(s string) string {
+	return statement(s)
+}
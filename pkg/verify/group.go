@@ -0,0 +1,221 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// Group verifies a testcase against multiple channels at once. It fans out one
+// Runner per channel. Each runner verifies the expectations recorded from its
+// channel, the group merges the results back into a single testcase.
+type Group struct {
+	testname   string
+	channels   []df.Channel
+	repository df.TestRepository
+	runners    []*Runner
+
+	mu       sync.Mutex
+	testcase df.Testcase // merged verification results
+	aborted  bool        // Start failed, results aren't written
+}
+
+// NewGroup creates a new group for verifying interactions of the given
+// channels. logs[i] must be the log of channels[i].
+func NewGroup(testname string, channels []df.Channel, config df.Config, logs []df.Log, repository df.TestRepository) *Group {
+	g := &Group{testname: testname, channels: channels, repository: repository}
+	for i, channel := range channels {
+		w := channelWriter{TestRepository: repository, group: g, channel: channel.Name}
+		g.runners = append(g.runners, NewRunner(testname, channel, config, logs[i], w))
+	}
+	return g
+}
+
+// Start loads the testcase and starts the runners of all channels.
+// Expectations that were recorded before expectations got tagged with their
+// channel are assigned to the first channel. If a runner fails to start, the
+// runners started so far are stopped without writing their results and the
+// logs of all channels are closed.
+func (g *Group) Start() error {
+	if err := g.load(); err != nil {
+		g.abort(0)
+		return err
+	}
+	for i, r := range g.runners {
+		if err := r.Start(); err != nil {
+			g.abort(i)
+			return err
+		}
+	}
+	return nil
+}
+
+// abort stops the first n runners, that have been started, and closes the logs
+// of the others.
+func (g *Group) abort(n int) {
+	g.mu.Lock()
+	g.aborted = true
+	g.mu.Unlock()
+	for i, r := range g.runners {
+		if i < n {
+			_ = r.Stop()
+		} else {
+			r.channelLog.Close()
+		}
+	}
+}
+
+// load loads the testcase from the repository and resets the expectations of
+// the group's channels. Expectations of other channels keep their results.
+func (g *Group) load() error {
+	tc, err := g.repository.Get(g.testname)
+	if err != nil {
+		return err
+	}
+	for i, e := range tc.Expectations {
+		if e.Channel == "" && len(g.channels) > 0 {
+			tc.Expectations[i].Channel = g.channels[0].Name
+		}
+		if includes(g.channels, tc.Expectations[i].Channel) {
+			tc.Expectations[i].Fulfilled = false
+		}
+	}
+	g.testcase = tc
+	return nil
+}
+
+func includes(channels []df.Channel, name string) bool {
+	for _, c := range channels {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the runners of all channels and waits till each runner has written
+// its results.
+func (g *Group) Stop() error {
+	var result error
+	for _, r := range g.runners {
+		if err := r.Stop(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// Switches returns the log file switches of all channels.
+func (g *Group) Switches() []df.LogSwitch {
+	var switches []df.LogSwitch
+	for _, r := range g.runners {
+		switches = append(switches, r.Switches()...)
+	}
+	return switches
+}
+
+// Testcase returns the testcase merged from the current verification state of
+// all channels.
+func (g *Group) Testcase() df.Testcase {
+	g.mu.Lock()
+	tc := g.copyTestcase()
+	g.mu.Unlock()
+	tc.AdditionalExpectations = nil
+	for _, r := range g.runners {
+		if r.started() == nil {
+			continue
+		}
+		rtc := r.Testcase()
+		tc = merge(tc, r.channel.Name, rtc)
+		tc.AdditionalExpectations = append(tc.AdditionalExpectations, rtc.AdditionalExpectations...)
+	}
+	if err := g.Err(); err != nil {
+		tc.Error = err.Error()
+	}
+	return tc
+}
+
+// Err returns the errors that aborted the verification of single channels or
+// nil. The runners of the other channels keep verifying.
+func (g *Group) Err() error {
+	var errs []error
+	for _, r := range g.runners {
+		if err := r.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("channel '%s': %w", r.channel.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReportResults creates a [df.Report] of the verification results of all
+// channels of the group.
+func (g *Group) ReportResults() df.Report {
+	return report(g.testname, g.Testcase(), g.channels)
+}
+
+// report creates a [df.Report] of the expectations of tc that were recorded
+// from one of channels, the expectations of other channels weren't verified.
+func report(testname string, tc df.Testcase, channels []df.Channel) df.Report {
+	var expectations []df.Expectation
+	for _, e := range tc.Expectations {
+		if includes(channels, e.Channel) {
+			expectations = append(expectations, e)
+		}
+	}
+	tc.Expectations = expectations
+	return df.NewReport(testname, tc)
+}
+
+// copyTestcase returns a copy of the merged testcase that doesn't share its
+// expectations with g.testcase.
+func (g *Group) copyTestcase() df.Testcase {
+	tc := g.testcase
+	tc.Expectations = append([]df.Expectation(nil), g.testcase.Expectations...)
+	return tc
+}
+
+// write merges the expectations verified on channel into the group's testcase
+// and writes the result to the repository.
+func (g *Group) write(channel string, tc df.Testcase) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.aborted {
+		return nil
+	}
+	g.testcase = merge(g.testcase, channel, tc)
+	return g.repository.Write(g.testname, g.testcase)
+}
+
+// merge takes the expectations of channel from tc and everything else from
+// base. Both testcases must contain the same expectations in the same order.
+func merge(base df.Testcase, channel string, tc df.Testcase) df.Testcase {
+	base.Verifications = tc.Verifications
+	base.LastExecution = tc.LastExecution
+	for i, e := range tc.Expectations {
+		if i < len(base.Expectations) && e.Channel == channel {
+			base.Expectations[i] = e
+		}
+	}
+	return base
+}
+
+// channelWriter hands the group's testcase to the runner of a single channel
+// and passes its results back to the group instead of writing them directly to
+// the repository.
+type channelWriter struct {
+	df.TestRepository
+	group   *Group
+	channel string
+}
+
+func (w channelWriter) Get(_ string) (df.Testcase, error) {
+	w.group.mu.Lock()
+	defer w.group.mu.Unlock()
+	return w.group.copyTestcase(), nil
+}
+
+func (w channelWriter) Write(_ string, tc df.Testcase) error {
+	return w.group.write(w.channel, tc)
+}
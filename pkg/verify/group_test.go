@@ -0,0 +1,61 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	df.RegisterFormat(mocks.Format)
+}
+
+func TestMergeTakesOnlyExpectationsOfChannel(t *testing.T) {
+	base := df.Testcase{Name: "create-job", Expectations: []df.Expectation{
+		{Channel: "mysql", Uuid: "1"},
+		{Channel: "postgres", Uuid: "2"},
+	}}
+	tc := df.Testcase{Name: "create-job", Verifications: 3, Expectations: []df.Expectation{
+		{Channel: "mysql", Uuid: "1", Fulfilled: true, Verified: 3},
+		{Channel: "postgres", Uuid: "2", Fulfilled: true, Verified: 3},
+	}}
+
+	merged := merge(base, "postgres", tc)
+	assert.Equal(t, 3, merged.Verifications)
+	assert.False(t, merged.Expectations[0].Fulfilled)
+	assert.True(t, merged.Expectations[1].Fulfilled)
+	assert.Equal(t, 3, merged.Expectations[1].Verified)
+}
+
+func TestGroupStopsStartedRunnersIfStartFails(t *testing.T) {
+	channels := []df.Channel{{Name: "postgres", Format: "postgres"}, {Name: "oracle", Format: "oracle"}}
+	logs := []*mocks.SQLLog{{}, {}}
+	tc := df.Testcase{Name: "create-job", Verifications: 2, Expectations: []df.Expectation{{Channel: "postgres", Uuid: "1", Fulfilled: true}}}
+	repository := &mocks.TestRepository{Testcases: []df.Testcase{tc}}
+	g := NewGroup("create-job", channels, df.Config{}, []df.Log{logs[0], logs[1]}, repository)
+
+	assert.Error(t, g.Start())
+	<-g.runners[0].stopped
+	assert.True(t, logs[0].Closed)
+	assert.True(t, logs[1].Closed)
+	assert.Equal(t, []df.Testcase{tc}, repository.Testcases)
+}
+
+func TestGroupTestcaseWhileStarting(t *testing.T) {
+	channels := []df.Channel{{Name: "mysql", Format: "mock"}}
+	repository := &mocks.TestRepository{Testcases: []df.Testcase{{Name: "create-job"}}}
+	g := NewGroup("create-job", channels, df.Config{}, []df.Log{&mocks.SQLLog{}}, repository)
+
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		for i := 0; i < 100; i++ {
+			g.Testcase()
+		}
+	}()
+	assert.NoError(t, g.Start())
+	<-read
+	assert.NoError(t, g.Stop())
+}
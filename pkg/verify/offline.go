@@ -0,0 +1,40 @@
+package verify
+
+import (
+	"io"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+)
+
+// VerifyLog verifies testname offline against the finished log r of channel,
+// e.g. a database log kept as CI artifact. r may be gzip compressed. Only
+// statements logged between from and to are considered, zero times leave the
+// period open.
+func VerifyLog(testname string, channel df.Channel, config df.Config, r io.Reader, from, to time.Time, repository df.TestRepository) (df.Report, error) {
+	format, err := df.LookupFormat(channel.Format)
+	if err != nil {
+		return df.Report{}, err
+	}
+	tokenizer, err := df.NewTokenizer(format.Tokenizer, channel)
+	if err != nil {
+		return df.Report{}, err
+	}
+
+	content, err := df.Decompress(r)
+	if err != nil {
+		return df.Report{}, err
+	}
+	channelLog := format.LogFactory(channel).Static(content)
+	defer channelLog.Close()
+
+	g := NewGroup(testname, []df.Channel{channel}, config, []df.Log{channelLog}, repository)
+	if err := g.load(); err != nil {
+		return df.Report{}, err
+	}
+	w := channelWriter{TestRepository: repository, group: g, channel: channel.Name}
+	tc, _ := w.Get(testname)
+	verifier := NewVerifier(config, channel, w, tokenizer, channelLog, tc, df.FixedTimer{From: from, To: to}, testname)
+	err = verifier.Run()
+	return report(testname, verifier.Testcase(), []df.Channel{channel}), err
+}
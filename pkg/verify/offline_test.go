@@ -0,0 +1,84 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/datafrog/pkg/df"
+	"github.com/rwirdemann/datafrog/pkg/mocks"
+	_ "github.com/rwirdemann/datafrog/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyLog(t *testing.T) {
+	logs := "2024-04-08T12:50:58.000000Z	 2609 Query	select * from jobs where id=1;\n" +
+		"2024-04-08T12:51:00.000000Z	 2609 Query	select * from jobs where id=2;\n"
+	tc := df.Testcase{Name: "create-job", Expectations: []df.Expectation{
+		{Channel: "mysql", Tokens: df.Tokenize("select * from jobs where id=1;"), Pattern: "select *"},
+	}}
+	channel := df.Channel{Name: "mysql", Format: "mysql", Patterns: []string{"select *"}}
+	repository := &mocks.TestRepository{Testcases: []df.Testcase{tc}}
+	from, _ := time.Parse(time.RFC3339, "2024-04-08T12:50:59Z")
+
+	report, err := VerifyLog("create-job", channel, df.Config{}, strings.NewReader(logs), from, time.Time{}, repository)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Fulfilled)
+	assert.Equal(t, 1, report.Verifications)
+
+	// the diff was learned from the second statement, the first one is out of the period
+	all, _ := repository.All()
+	assert.Equal(t, []int{5}, all[len(all)-1].Expectations[0].IgnoreDiffs)
+}
+
+func TestVerifyLogKeepsResultsOfOtherChannels(t *testing.T) {
+	logs := "2024-04-08T12:51:00.000000Z	 2609 Query	select * from jobs where id=1;\n"
+	tc := df.Testcase{Name: "create-job", Expectations: []df.Expectation{
+		{Channel: "mysql", Tokens: df.Tokenize("select * from jobs where id=1;"), Pattern: "select *"},
+		{Channel: "postgres", Tokens: df.Tokenize("insert into jobs (id) values (1)"), Pattern: "insert", Fulfilled: true, Verified: 1},
+	}}
+	channel := df.Channel{Name: "mysql", Format: "mysql", Patterns: []string{"select *"}}
+	repository := &mocks.TestRepository{Testcases: []df.Testcase{tc}}
+
+	report, err := VerifyLog("create-job", channel, df.Config{}, strings.NewReader(logs), time.Time{}, time.Time{}, repository)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Expectations)
+	assert.Equal(t, 1, report.Fulfilled)
+	assert.Len(t, report.Channels, 1)
+	all, _ := repository.All()
+	assert.True(t, all[len(all)-1].Expectations[1].Fulfilled)
+}
+
+func TestVerifyLogNormalizesHibernateAliases(t *testing.T) {
+	recorded := "select job0_.id as id1_0_, job0_.description as descript2_0_ from job job0_ where job0_.id=1"
+	logs := "2024-04-08T12:51:00.000000Z	 2609 Query	select job0_.id as id1_0_, job0_.title as title3_0_, job0_.description as descript2_0_ from job job0_ where job0_.id=1\n" +
+		"2024-04-08T12:51:01.000000Z	 2609 Query	select job1_.id as id1_1_, job1_.description as descript3_1_ from job job1_ where job1_.id=1\n"
+	channel := df.Channel{Name: "mysql", Format: "mysql", Patterns: []string{"select job"}, Normalize: []string{"hibernate"}}
+	tc := df.Testcase{Name: "show-job", Expectations: []df.Expectation{
+		{Channel: "mysql", Tokens: df.HibernateAliases{}.Normalize(df.Tokenize(recorded)), Pattern: "select job"},
+	}}
+	repository := &mocks.TestRepository{Testcases: []df.Testcase{tc}}
+
+	report, err := VerifyLog("show-job", channel, df.Config{}, strings.NewReader(logs), time.Time{}, time.Time{}, repository)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Fulfilled)
+	all, _ := repository.All()
+	assert.Empty(t, all[len(all)-1].Expectations[0].IgnoreDiffs)
+}
+
+func TestVerifyLogLearnsDiffsOfLogicalDecoding(t *testing.T) {
+	logs := "BEGIN 530\n" +
+		"table public.job: INSERT: id[integer]:6 description[text]:'World' publish_trials[integer]:0\n" +
+		"COMMIT 530 (at 2024-04-08 12:51:00.5+00)\n"
+	channel := df.Channel{Name: "postgres", Format: "postgres-logical", Patterns: []string{"insert into public.job"}}
+	tc := df.Testcase{Name: "create-job", Expectations: []df.Expectation{
+		{Channel: "postgres", Tokens: df.Tokenize("insert into public.job id=5 description='World' publish_trials=0"), Pattern: "insert into public.job"},
+	}}
+	repository := &mocks.TestRepository{Testcases: []df.Testcase{tc}}
+
+	report, err := VerifyLog("create-job", channel, df.Config{}, strings.NewReader(logs), time.Time{}, time.Time{}, repository)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Fulfilled)
+	all, _ := repository.All()
+	assert.Equal(t, []int{3}, all[len(all)-1].Expectations[0].IgnoreDiffs)
+}
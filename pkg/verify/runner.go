@@ -1,9 +1,9 @@
 package verify
 
 import (
+	"sync"
+
 	"github.com/rwirdemann/datafrog/pkg/df"
-	"github.com/rwirdemann/datafrog/pkg/mysql"
-	"github.com/rwirdemann/datafrog/pkg/postgres"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -14,7 +14,9 @@ type Runner struct {
 	config     df.Config
 	channelLog df.Log
 	repository df.TestRepository
-	verifier   *Verifier
+	verifier   *Verifier  // nil until the runner was started
+	mu         sync.Mutex // guards verifier, that is read while starting
+	timer      df.Timer
 	done       chan struct{}
 	stopped    chan struct{}
 }
@@ -29,28 +31,35 @@ func NewRunner(testname string, channel df.Channel, config df.Config, log df.Log
 func (r *Runner) Start() error {
 	tc, err := r.repository.Get(r.testname)
 	if err != nil {
-		return nil
+		return err
 	}
-	var tokenizer df.Tokenizer
-	if r.channel.Format == "mysql" {
-		tokenizer = mysql.Tokenizer{}
+	format, err := df.LookupFormat(r.channel.Format)
+	if err != nil {
+		return err
 	}
-	if r.channel.Format == "postgres" {
-		tokenizer = postgres.Tokenizer{}
+	tokenizer, err := df.NewTokenizer(format.Tokenizer, r.channel)
+	if err != nil {
+		return err
 	}
 
-	r.verifier = NewVerifier(r.config, r.channel, r.repository, tokenizer, r.channelLog, tc, &df.UTCTimer{}, r.testname)
+	r.timer = &df.UTCTimer{}
+	verifier := NewVerifier(r.config, r.channel, r.repository, tokenizer, r.channelLog, tc, r.timer, r.testname)
 	r.done = make(chan struct{})
 	r.stopped = make(chan struct{})
-	go r.verifier.Start(r.done, r.stopped)
+	r.mu.Lock()
+	r.verifier = verifier
+	r.mu.Unlock()
+	go verifier.Start(r.done, r.stopped)
 	return nil
 }
 
 // Stop stops the verification by closing the done channel, that is checked by the
-// verifier for its termination. Closes also the channels log file and test
-// writer.
+// verifier for its termination. The recording period ends now, but the verifier
+// keeps reading statements that are flushed late to the log. Closes also the
+// channels log file and test writer.
 func (r *Runner) Stop() error {
 	// tell verifier that verification has been finished
+	r.timer.Stop()
 	close(r.done)
 	log.Printf("vrunner: waiting for stopped channel to be closed")
 
@@ -66,5 +75,36 @@ func (r *Runner) Stop() error {
 
 // Testcase returns the testcase.
 func (r *Runner) Testcase() df.Testcase {
-	return r.verifier.testcase
+	return r.started().Testcase()
+}
+
+// Err returns the error that aborted the verification or nil.
+func (r *Runner) Err() error {
+	verifier := r.started()
+	if verifier == nil {
+		return nil
+	}
+	return verifier.Err()
+}
+
+// started returns the verifier of r or nil if r wasn't started yet.
+func (r *Runner) started() *Verifier {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.verifier
+}
+
+// Switches returns the rotations and truncations of the channel's log file
+// tagged with the channel.
+func (r *Runner) Switches() []df.LogSwitch {
+	f, ok := r.channelLog.(df.Follower)
+	if !ok {
+		return nil
+	}
+	var switches []df.LogSwitch
+	for _, s := range f.Switches() {
+		s.Channel = r.channel.Name
+		switches = append(switches, s)
+	}
+	return switches
 }
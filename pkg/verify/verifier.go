@@ -1,9 +1,13 @@
 package verify
 
 import (
-	log "github.com/sirupsen/logrus"
+	"errors"
+	"io"
+	"sync"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/rwirdemann/datafrog/pkg/df"
 )
 
@@ -20,6 +24,8 @@ type Verifier struct {
 	testcase   df.Testcase
 	timer      df.Timer
 	name       string
+	err        error      // error that aborted the verification
+	mu         sync.Mutex // guards testcase and err, that are read while verifying
 }
 
 // NewVerifier creates a new Verifier.
@@ -43,43 +49,39 @@ func NewVerifier(
 		name:       name,
 	}
 }
+
+// Testcase returns a copy of the testcase in its current verification state.
 func (verifier *Verifier) Testcase() df.Testcase {
-	return verifier.testcase
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+	tc := verifier.testcase
+	tc.Expectations = append([]df.Expectation(nil), verifier.testcase.Expectations...)
+	tc.AdditionalExpectations = append([]df.Expectation(nil), verifier.testcase.AdditionalExpectations...)
+	return tc
 }
 
 // Start runs the verification loop. Stops when done channel was closed. Closes
 // stopped channel afterward in order to tell its caller (web, cli, ...) that
-// verification has been finished.
+// verification has been finished. Only expectations that were recorded from the
+// verifier's channel are considered, expectations that were recorded before
+// expectations got tagged with their channel are assigned to it. If reading the log fails the verification
+// stops and the error is kept, see Err. The results verified so far are written
+// anyway. After done was closed, the log is drained up to the stop time of the
+// timer, see [df.Drain].
 func (verifier *Verifier) Start(done chan struct{}, stopped chan struct{}) {
-	verifier.timer.Start()
+	verifier.begin()
 	log.Printf("verification started at %v...", verifier.timer.GetStart())
-	verifier.testcase.Verifications = verifier.testcase.Verifications + 1
-	verifier.testcase.LastExecution = time.Now()
-	for i := range verifier.testcase.Expectations {
-		verifier.testcase.Expectations[i].Fulfilled = false
-	}
 
 	// tell caller that verification has been finished
 	defer close(stopped)
 
 	// called when done channel is closed
-	defer func() {
-		// create a write copy of the testcase to make sure no additional expectations
-		// are saved but kept for reporting reasons
-		tc := verifier.testcase
-
-		// don't write additional expectations
-		tc.AdditionalExpectations = nil
-
-		if err := verifier.repository.Write(tc.Name, tc); err != nil {
-			log.Fatal(err)
-		}
-		//verifier.write()
-	}()
+	defer verifier.write()
 
 	// jump to log file end
 	if err := verifier.log.Tail(); err != nil {
-		log.Fatal(err)
+		verifier.fail(err)
+		return
 	}
 
 	for {
@@ -87,43 +89,131 @@ func (verifier *Verifier) Start(done chan struct{}, stopped chan struct{}) {
 		default:
 			v, err := verifier.log.NextLine(done)
 			if err != nil {
-				log.Fatal(err)
-			}
-
-			ts, err := verifier.log.Timestamp(v)
-			if err != nil {
-				continue
-			}
-			if verifier.timer.MatchesRecordingPeriod(ts) {
-				matches, vPattern := df.MatchesPattern(verifier.channel.Patterns, v)
-				if !matches {
-					continue
-				}
-
-				verified := verifier.verify(v, vPattern)
-
-				if !verified && verifier.config.Expectations.ReportAdditional {
-
-					// v matches pattern but no matching expectation was found
-					expectation := df.Expectation{
-						Tokens: verifier.tokenizer.Tokenize(v, verifier.channel.Patterns), Pattern: vPattern,
-					}
-					log.Printf("additional expectation found: %s\n", expectation.Shorten(6))
-					verifier.testcase.AdditionalExpectations = append(verifier.testcase.AdditionalExpectations, expectation)
-				}
+				verifier.fail(err)
+				return
 			}
+			verifier.process(v)
 		case <-done:
 			log.Printf("verifier: done channel closed")
+
+			// verify statements that were flushed to the log after the stop
+			if err := df.Drain(verifier.log, verifier.timer, verifier.channel.Grace(), verifier.process); err != nil {
+				verifier.fail(err)
+			}
 			return
 		}
 	}
 }
 
+// Run verifies the expectations against all statements of a finished log, see
+// [df.LogFactory.Static]. Unlike Start, Run reads the log from its current
+// position instead of tailing it and returns at its end. The results are
+// written even if reading the log fails.
+func (verifier *Verifier) Run() error {
+	verifier.begin()
+	log.Printf("verifying %s between %v and %v...", verifier.name, verifier.timer.GetStart(), verifier.timer.GetStop())
+	for {
+		v, err := verifier.log.NextLine(nil)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			verifier.fail(err)
+			break
+		}
+		verifier.process(v)
+	}
+	verifier.write()
+	return verifier.Err()
+}
+
+// begin starts the timer and prepares the testcase for a new verification run.
+func (verifier *Verifier) begin() {
+	verifier.timer.Start()
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+	verifier.testcase.Verifications = verifier.testcase.Verifications + 1
+	verifier.testcase.LastExecution = time.Now()
+	for i, e := range verifier.testcase.Expectations {
+		if e.Channel == "" {
+			verifier.testcase.Expectations[i].Channel = verifier.channel.Name
+		}
+		if verifier.testcase.Expectations[i].Channel == verifier.channel.Name {
+			verifier.testcase.Expectations[i].Fulfilled = false
+		}
+	}
+}
+
+// write writes the verified testcase back to the repository.
+func (verifier *Verifier) write() {
+	// create a write copy of the testcase to make sure no additional expectations
+	// are saved but kept for reporting reasons
+	tc := verifier.Testcase()
+
+	// don't write additional expectations
+	tc.AdditionalExpectations = nil
+
+	if err := verifier.repository.Write(tc.Name, tc); err != nil {
+		verifier.fail(err)
+	}
+}
+
+// process verifies v if it falls in the recording period and matches one of the
+// channels patterns. Keeps v as additional expectation if it can't be verified
+// and additional expectations should be reported.
+func (verifier *Verifier) process(v string) {
+	ts, err := verifier.log.Timestamp(v)
+	if err != nil {
+		return
+	}
+	if !df.InRecordingPeriod(verifier.timer, verifier.log, ts) {
+		return
+	}
+	matches, vPattern := df.MatchesPattern(verifier.channel.Patterns, df.Statement(verifier.tokenizer, v))
+	if !matches {
+		return
+	}
+
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+	verified := verifier.verify(v, vPattern)
+
+	if !verified && verifier.config.Expectations.ReportAdditional {
+
+		// v matches pattern but no matching expectation was found
+		expectation := df.Expectation{
+			Channel: verifier.channel.Name,
+			Tokens:  verifier.tokenizer.Tokenize(v, verifier.channel.Patterns), Pattern: vPattern,
+			Metadata: df.Metadata(verifier.tokenizer, v),
+			Original: df.Original(verifier.tokenizer, v, verifier.channel.Patterns),
+		}
+		log.Printf("additional expectation found: %s\n", expectation.Shorten(6))
+		verifier.testcase.AdditionalExpectations = append(verifier.testcase.AdditionalExpectations, expectation)
+	}
+}
+
+// fail keeps the first error that aborted the verification.
+func (verifier *Verifier) fail(err error) {
+	log.Errorf("verifier: %v", err)
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+	if verifier.err == nil {
+		verifier.err = err
+	}
+}
+
+// Err returns the error that aborted the verification or nil.
+func (verifier *Verifier) Err() error {
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+	return verifier.err
+}
+
 // verify tries to verify one of the testcases expectations. Returns true if an
 // expectation was verified and false otherwise.
 func (verifier *Verifier) verify(v string, vPattern string) bool {
 	for i, e := range verifier.testcase.Expectations {
-		if e.Fulfilled || e.Pattern != vPattern {
+		if e.Fulfilled || e.Pattern != vPattern || e.Channel != verifier.channel.Name {
 			continue // -> continue with next e
 		}
 
@@ -134,6 +224,7 @@ func (verifier *Verifier) verify(v string, vPattern string) bool {
 			log.Printf("expectation verified by: %s\n", df.Expectation{Tokens: vTokens}.Shorten(6))
 			verifier.testcase.Expectations[i].Fulfilled = true
 			verifier.testcase.Expectations[i].Verified = e.Verified + 1
+			verifier.testcase.Expectations[i].Metadata = df.Metadata(verifier.tokenizer, v)
 			return true // -> continue with next v
 		}
 
@@ -149,6 +240,7 @@ func (verifier *Verifier) verify(v string, vPattern string) bool {
 				verifier.testcase.Expectations[i].IgnoreDiffs = diff
 				verifier.testcase.Expectations[i].Fulfilled = true
 				verifier.testcase.Expectations[i].Verified = 1
+				verifier.testcase.Expectations[i].Metadata = df.Metadata(verifier.tokenizer, v)
 				return true // -> continue with next v
 			}
 		}
@@ -156,38 +248,7 @@ func (verifier *Verifier) verify(v string, vPattern string) bool {
 	return false // -> expectation not verified
 }
 
-// ReportResults creates a [domain.Report] of the verification results.
+// ReportResults creates a [df.Report] of the verification results.
 func (verifier *Verifier) ReportResults() df.Report {
-	fulfilled := 0
-	verifiedSum := 0
-	for _, e := range verifier.testcase.Expectations {
-		verifiedSum += e.Verified
-		if e.Fulfilled {
-			fulfilled = fulfilled + 1
-		}
-	}
-	report := df.Report{
-		Testname:         verifier.name,
-		LastExecution:    time.Now(),
-		Expectations:     len(verifier.testcase.Expectations),
-		Verifications:    verifier.testcase.Verifications,
-		Fulfilled:        fulfilled,
-		VerificationMean: verificationMean(float32(verifiedSum), float32(len(verifier.testcase.Expectations))),
-	}
-	for _, e := range verifier.testcase.Expectations {
-		if !e.Fulfilled {
-			report.Unfulfilled = append(report.Unfulfilled, e)
-		}
-	}
-	for _, e := range verifier.testcase.AdditionalExpectations {
-		report.AdditionalExpectations = append(report.AdditionalExpectations, e.Shorten(6))
-	}
-	return report
-}
-
-func verificationMean(sum, expectationCount float32) float32 {
-	if expectationCount > 0 {
-		return sum / expectationCount
-	}
-	return 0
+	return df.NewReport(verifier.name, verifier.Testcase())
 }
@@ -1,6 +1,7 @@
 package verify
 
 import (
+	"errors"
 	"github.com/rwirdemann/datafrog/pkg/df"
 	"github.com/rwirdemann/datafrog/pkg/mocks"
 	"github.com/rwirdemann/datafrog/pkg/mysql"
@@ -322,3 +323,73 @@ func TestVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyIgnoresExpectationsOfOtherChannels(t *testing.T) {
+	c := df.Config{}
+	c.Channels = []df.Channel{{Name: "mysql", Patterns: []string{"select *"}}}
+	doneChannel := make(chan struct{})
+	stoppedChannel := make(chan struct{})
+	databaseLog := mocks.NewMemSQLLog([]string{
+		"2024-04-08T09:39:15.070009Z	 2549 Query	select * from jobs;",
+		"STOP",
+	}, doneChannel)
+	tc := df.Testcase{Name: "create-job", Expectations: []df.Expectation{
+		{Channel: "postgres", Tokens: df.Tokenize("select * from jobs;"), Pattern: "select *"},
+		{Channel: "mysql", Tokens: df.Tokenize("select * from jobs;"), Pattern: "select *"},
+	}}
+	repository := &mocks.TestRepository{}
+	verifier := NewVerifier(c, c.Channels[0], repository, mysql.Tokenizer{}, databaseLog, tc, mocks.Timer{}, "create-job")
+	go verifier.Start(doneChannel, stoppedChannel)
+	<-stoppedChannel
+
+	expectations := verifier.Testcase().Expectations
+	assert.False(t, expectations[0].Fulfilled)
+	assert.True(t, expectations[1].Fulfilled)
+
+	report := verifier.ReportResults()
+	assert.Len(t, report.Channels, 2)
+	assert.Equal(t, df.ChannelReport{Channel: "postgres", Expectations: 1, Fulfilled: 0, Unfulfilled: expectations[:1]}, report.Channels[0])
+	assert.Equal(t, 1, report.Channels[1].Fulfilled)
+}
+
+func TestVerifyAssignsExpectationsWithoutChannel(t *testing.T) {
+	c := df.Config{}
+	c.Channels = []df.Channel{{Name: "mysql", Patterns: []string{"select *"}}}
+	doneChannel := make(chan struct{})
+	stoppedChannel := make(chan struct{})
+	databaseLog := mocks.NewMemSQLLog([]string{
+		"2024-04-08T09:39:15.070009Z	 2549 Query	select * from jobs;",
+		"STOP",
+	}, doneChannel)
+
+	// expectations recorded before they got tagged with their channel
+	tc := df.Testcase{Name: "create-job", Expectations: []df.Expectation{{Tokens: df.Tokenize("select * from jobs;"), Pattern: "select *"}}}
+	repository := &mocks.TestRepository{}
+	verifier := NewVerifier(c, c.Channels[0], repository, mysql.Tokenizer{}, databaseLog, tc, mocks.Timer{}, "create-job")
+	go verifier.Start(doneChannel, stoppedChannel)
+	<-stoppedChannel
+
+	actual, err := repository.Get("create-job")
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql", actual.Expectations[0].Channel)
+	assert.True(t, actual.Expectations[0].Fulfilled)
+}
+
+func TestVerifyStopsOnLogError(t *testing.T) {
+	c := df.Config{}
+	c.Channels = []df.Channel{{Patterns: []string{"select *"}}}
+	logErr := errors.New("log file vanished")
+	databaseLog := mocks.NewFailingSQLLog([]string{"2024-04-08T09:39:15.070009Z	 2549 Query	select * from jobs;"}, logErr)
+	tc := df.Testcase{Name: "create-job", Expectations: []df.Expectation{{Tokens: df.Tokenize("select * from jobs;"), Pattern: "select *"}}}
+	repository := &mocks.TestRepository{}
+	verifier := NewVerifier(c, c.Channels[0], repository, mysql.Tokenizer{}, databaseLog, tc, mocks.Timer{}, "create-job")
+	stoppedChannel := make(chan struct{})
+	go verifier.Start(make(chan struct{}), stoppedChannel)
+	<-stoppedChannel
+	assert.ErrorIs(t, verifier.Err(), logErr)
+
+	// the results verified so far are written anyway
+	actual, err := repository.Get("create-job")
+	assert.NoError(t, err)
+	assert.True(t, actual.Expectations[0].Fulfilled)
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -168,7 +169,8 @@ func ShowHandler(w http.ResponseWriter, r *http.Request) {
 	simpleweb.Render("templates/show.html", w, struct {
 		Title    string
 		Testcase df.Testcase
-	}{Title: "Show", Testcase: tc})
+		Report   df.Report
+	}{Title: "Show", Testcase: tc, Report: df.NewReport(tc.Name, tc)})
 }
 
 // getTestcase fetches and returns test "name".
@@ -233,12 +235,17 @@ func ProgressRecordingHandler(w http.ResponseWriter, r *http.Request) {
 		progress = 100
 	}
 
+	if tc.Error != "" {
+		color = "is-danger"
+	}
+
 	if err := simpleweb.RenderPartialE("templates/progress-recording.html", w, struct {
 		Progress     int
 		Testname     string
 		Color        string
 		Expectations int
-	}{Progress: progress, Testname: testname, Color: color, Expectations: len(tc.Expectations)}); err != nil {
+		Error        string
+	}{Progress: progress, Testname: testname, Color: color, Expectations: len(tc.Expectations), Error: tc.Error}); err != nil {
 		log.Errorf("Error rendering partial %v", err)
 	}
 }
@@ -246,8 +253,9 @@ func ProgressRecordingHandler(w http.ResponseWriter, r *http.Request) {
 // NewHandler renders the new templates
 func NewHandler(w http.ResponseWriter, _ *http.Request) {
 	simpleweb.Render("templates/new.html", w, struct {
-		Title string
-	}{Title: "Record"})
+		Title    string
+		Channels []df.Channel
+	}{Title: "Record", Channels: config.Channels})
 }
 
 // StartRecording creates / overrides the test form["testname"] and starts its
@@ -259,7 +267,15 @@ func StartRecording(runner driver.PlaywrightRunner) http.HandlerFunc {
 			simpleweb.RedirectE(w, request, "/", err)
 			return
 		}
-		res, err := Post(fmt.Sprintf("%s/tests/%s/recordings", apiBaseURL, testname))
+		body, err := json.Marshal(struct {
+			Channels    []string `json:"channels"`
+			Description string   `json:"description"`
+		}{Channels: request.PostForm["channels"], Description: request.PostFormValue("description")})
+		if err != nil {
+			simpleweb.RedirectE(w, request, "/", err)
+			return
+		}
+		res, err := Post(fmt.Sprintf("%s/tests/%s/recordings", apiBaseURL, testname), bytes.NewReader(body))
 		if err != nil {
 			simpleweb.RedirectE(w, request, "/", err)
 			return
@@ -387,13 +403,17 @@ func ProgressVerificationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	fulfilled := len(tc.Fulfilled())
 	p, c := calcProgressAndCssClass(tc)
+	if tc.Error != "" {
+		c = "is-danger"
+	}
 	if err := simpleweb.RenderPartialE("templates/progress-verification.html", w, struct {
 		Progress     int
 		Testname     string
 		Color        string
 		Expectations int
 		Fulfilled    int
-	}{Progress: p, Testname: testname, Color: c, Expectations: len(tc.Expectations), Fulfilled: fulfilled}); err != nil {
+		Error        string
+	}{Progress: p, Testname: testname, Color: c, Expectations: len(tc.Expectations), Fulfilled: fulfilled, Error: tc.Error}); err != nil {
 		log.Errorf("Error rendering partial %v", err)
 	}
 }
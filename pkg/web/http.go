@@ -1,14 +1,17 @@
 package web
 
 import (
+	"io"
 	"net/http"
 )
 
-func Post(url string) (*http.Response, error) {
-	r, err := http.NewRequest(http.MethodPost, url, nil)
+// Post sends body as json encoded POST request to url.
+func Post(url string, body io.Reader) (*http.Response, error) {
+	r, err := http.NewRequest(http.MethodPost, url, body)
 	if err != nil {
 		return nil, err
 	}
+	r.Header.Set("Content-Type", "application/json")
 	res, err := client.Do(r)
 	if err != nil {
 		return nil, err